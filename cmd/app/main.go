@@ -1,43 +1,173 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
+	"evo-sim/internal/brain"
 	"evo-sim/internal/config"
+	"evo-sim/internal/entity"
+	"evo-sim/internal/replay"
 	"evo-sim/internal/server"
 	"evo-sim/internal/storage"
 	"evo-sim/internal/world"
 )
 
 func main() {
+	runID := flag.String("run", storage.DefaultRun, "run ID to record history under")
+	forkSnapshot := flag.Int64("fork", 0, "start a new run from this snapshot ID instead of a random world")
+	forkRun := flag.String("fork-run", storage.DefaultRun, "run that owns the -fork snapshot")
+	overrides := overrideFlags{}
+	flag.Var(overrides, "set", "override a config value, e.g. -set MUTATION_RATE=0.2 (repeatable)")
+	recordPath := flag.String("record", "", "record the /ws frame stream to this file")
+	recordFPS := flag.Int("record-fps", 30, "frames per second to record")
+	replayPath := flag.String("replay", "", "serve a recording instead of running a simulation")
+	replayPort := flag.String("replay-port", "8080", "HTTP port in -replay mode")
+	flag.Parse()
+
+	// Replaying needs neither config nor storage, so recordings can be shared
+	if *replayPath != "" {
+		serveReplay(*replayPath, *replayPort)
+		return
+	}
+
+	// Overrides take precedence over .env, which never replaces set variables
+	for key, value := range overrides {
+		os.Setenv(key, value)
+	}
+
 	cfg := config.Load()
 	log.Println("Config loaded. World size:", cfg.WorldWidth, "x", cfg.WorldHeight)
+	if !brain.KnownType(cfg.BrainType) {
+		log.Fatalf("Unknown BRAIN_TYPE %q", cfg.BrainType)
+	}
+	if cfg.Plasticity && cfg.BrainType != brain.TypeElman {
+		log.Fatalf("PLASTICITY needs BRAIN_TYPE=%s; %s brains don't learn during life", brain.TypeElman, cfg.BrainType)
+	}
+	if !entity.KnownMotors(cfg.Motors) {
+		log.Fatalf("Unknown MOTORS %q", cfg.Motors)
+	}
+	if err := entity.SetDominance(cfg.Dominance); err != nil {
+		log.Fatal("Invalid DOMINANCE: ", err)
+	}
+	actions, err := world.NewActionSet(cfg.Actions)
+	if err != nil {
+		log.Fatal("Invalid ACTIONS: ", err)
+	}
+	sensors, err := world.NewSensorSet(cfg.Sensors, cfg.VisionRayCount)
+	if err != nil {
+		log.Fatal("Invalid SENSORS: ", err)
+	}
+	outputs := len(entity.Motors{Model: cfg.Motors, Strafe: cfg.Strafe}.Names()) + len(actions.Names())
+	if err := world.CheckSeeds(cfg.SeedGenomes, sensors, outputs); err != nil {
+		log.Fatal("Invalid SEED_GENOMES: ", err)
+	}
 
-	store := storage.NewStorage(cfg.DBPath)
+	store, err := storage.Open(cfg.StorageBackend, cfg.DBPath, cfg.SnapshotFormat, *runID)
+	if err != nil {
+		log.Fatal("Failed to open storage: ", err)
+	}
+	if *forkSnapshot != 0 {
+		if *forkRun == *runID {
+			log.Fatal("A fork needs its own -run ID so histories don't mix")
+		}
+		// Forking into a run that already has history would mix the two
+		if exists, err := hasRun(store); err != nil {
+			log.Fatal("Failed to list runs: ", err)
+		} else if exists {
+			log.Fatalf("Run %q already exists; fork into a new -run ID", *runID)
+		}
+	}
+	if err := registerRun(store); err != nil {
+		log.Fatal("Failed to record run: ", err)
+	}
+	retention := storage.RetentionPolicy{
+		Hourly: cfg.RetainHourlyFor,
+		Daily:  cfg.RetainDailyFor,
+		Weekly: cfg.RetainWeeklyFor,
+	}
 
-	w := world.NewWorld(cfg)
+	var w *world.World
+	if *forkSnapshot != 0 {
+		w = forkWorld(cfg, sensors, outputs, store, *forkRun, *forkSnapshot, overrides)
+	} else {
+		w = world.NewWorld(cfg)
+	}
 
 	srv := server.NewServer(w)
+	srv.RecordHistory(cfg.RewindLength, cfg.RewindFPS)
+
+	if *recordPath != "" {
+		stop, err := srv.Record(*recordPath, *recordFPS)
+		if err != nil {
+			log.Fatal("Failed to start recording: ", err)
+		}
+		log.Printf("Recording frames to %s", *recordPath)
+
+		// Finish the file cleanly on Ctrl+C
+		go func() {
+			sig := make(chan os.Signal, 1)
+			signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+			<-sig
+			stop()
+			os.Exit(0)
+		}()
+	}
 	go srv.Start(cfg.HTTPPort)
 
 	go func() {
-		ticker := time.NewTicker(15 * time.Minute)
+		ticker := time.NewTicker(cfg.SnapshotInterval)
 		for range ticker.C {
 			w.Mu.RLock()
-			store.SaveSnapshot(w.Creatures, w.Food)
+			snapshot := storage.CaptureWorld(w)
+			_, err := store.SaveSnapshot(snapshot)
 			w.Mu.RUnlock()
+
+			if err != nil {
+				log.Println("Error saving snapshot:", err)
+				continue
+			}
+			log.Printf("Snapshot saved. Creatures: %d", len(snapshot.Creatures))
+
+			if n, err := storage.Prune(store, retention); err != nil {
+				log.Println("Error pruning snapshots:", err)
+			} else if n > 0 {
+				log.Printf("Pruned %d old snapshots", n)
+			}
+		}
+	}()
+
+	// Persist births, deaths and lineage
+	go func() {
+		ticker := time.NewTicker(5 * time.Second)
+		for range ticker.C {
+			events := w.DrainEvents()
+			if len(events) == 0 {
+				continue
+			}
+			if err := store.SaveEvents(events); err != nil {
+				log.Println("Error saving events:", err)
+			}
+			if err := store.SaveLineage(storage.LineageFromEvents(events)); err != nil {
+				log.Println("Error saving lineage:", err)
+			}
 		}
 	}()
 
 	go func() {
 		ticker := time.NewTicker(1 * time.Minute)
 		for range ticker.C {
-			w.Mu.RLock()
-			creatureCount := len(w.Creatures)
-			w.Mu.RUnlock()
-			speciesCount := w.SpeciesManager.GetSpeciesCount()
-			log.Printf("Species Count: %d, Creatures: %d", speciesCount, creatureCount)
+			stats := collectStats(w)
+			log.Printf("Species Count: %d, Creatures: %d", stats.Species, stats.Creatures)
+			if err := store.SaveStats(stats); err != nil {
+				log.Println("Error saving stats:", err)
+			}
 		}
 	}()
 
@@ -48,3 +178,136 @@ func main() {
 		w.Update()
 	}
 }
+
+func collectStats(w *world.World) storage.StatsRecord {
+	w.Mu.RLock()
+	defer w.Mu.RUnlock()
+
+	stats := storage.StatsRecord{
+		Tick:      w.Tick,
+		Timestamp: time.Now().Unix(),
+		Creatures: len(w.Creatures),
+		Food:      len(w.Food),
+		Species:   w.SpeciesManager.GetSpeciesCount(),
+	}
+	totalEnergy := 0.0
+	genomes := make([]entity.Genome, 0, len(w.Creatures))
+	for _, c := range w.Creatures {
+		genomes = append(genomes, c.Genome)
+		if c.IsCarnivore {
+			stats.Carnivores++
+		}
+		if c.Generation > stats.MaxGeneration {
+			stats.MaxGeneration = c.Generation
+		}
+		totalEnergy += c.Energy
+		rate, strength := w.Mutation.For(c.Genome)
+		stats.MutationRate += rate
+		stats.MutationStrength += strength
+	}
+	if n := float64(len(w.Creatures)); n > 0 {
+		stats.MeanEnergy = totalEnergy / n
+		stats.MutationRate /= n
+		stats.MutationStrength /= n
+	}
+	stats.LinkedLD, stats.UnlinkedLD = entity.MeanLinkageDisequilibrium(genomes)
+	return stats
+}
+
+// registerRun records the run of store unless it is known already, so every
+// run is listed by evodb runs and pruned by evodb compact. Forks replace the
+// record with where they branched off.
+func registerRun(store storage.Storage) error {
+	exists, err := hasRun(store)
+	if err != nil || exists {
+		return err
+	}
+	return store.SaveRun(storage.RunInfo{ID: store.Run()})
+}
+
+// hasRun reports whether the store's run has been recorded before.
+func hasRun(store storage.Storage) (bool, error) {
+	runs, err := store.ListRuns()
+	if err != nil {
+		return false, err
+	}
+	for _, run := range runs {
+		if run.ID == store.Run() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// forkWorld restores a snapshot of another run as the starting point of this
+// run and records where it branched off.
+func forkWorld(cfg *config.Config, sensors *world.SensorSet, outputs int, store storage.Storage, fromRun string, snapshotID int64, overrides overrideFlags) *world.World {
+	source, err := storage.Open(cfg.StorageBackend, cfg.DBPath, cfg.SnapshotFormat, fromRun)
+	if err != nil {
+		log.Fatal("Failed to open source run: ", err)
+	}
+	snapshot, err := source.LoadSnapshot(snapshotID)
+	source.Close()
+	if err != nil {
+		log.Fatalf("Failed to load snapshot %d of run %q: %v", snapshotID, fromRun, err)
+	}
+
+	for _, c := range snapshot.Creatures {
+		if c.Brain == nil {
+			log.Fatalf("Snapshot %d has creatures without brains", snapshotID)
+		}
+		if !sensors.Fits(c.Genome) {
+			log.Fatalf("Snapshot creatures sense %s, not SENSORS=%s", world.GenomeSensors(c.Genome), sensors.Key())
+		}
+		if in, _, out := c.Brain.Shape(); in != sensors.Size() || out != outputs {
+			log.Fatalf("Snapshot brains don't match %d sensor inputs/%d outputs", sensors.Size(), outputs)
+		}
+	}
+
+	err = store.SaveRun(storage.RunInfo{
+		ID:             store.Run(),
+		ParentRun:      fromRun,
+		ParentSnapshot: snapshotID,
+		Overrides:      overrides,
+	})
+	if err != nil {
+		log.Fatal("Failed to record run: ", err)
+	}
+
+	log.Printf("Forked run %q from snapshot %d of run %q (%d creatures, overrides %v)",
+		store.Run(), snapshotID, fromRun, len(snapshot.Creatures), overrides)
+	return world.NewWorldFromState(cfg, snapshot.Terrain, snapshot.Creatures, snapshot.Food, snapshot.Tick)
+}
+
+func serveReplay(path, port string) {
+	rec, err := replay.Load(path)
+	if err != nil {
+		log.Fatal("Failed to load recording: ", err)
+	}
+	if len(rec.Frames) == 0 {
+		log.Fatal("Recording has no frames")
+	}
+
+	first, last := rec.Frames[0], rec.Frames[len(rec.Frames)-1]
+	log.Printf("Replaying %s: %d frames, ticks %d-%d (%s) on port %s", path, len(rec.Frames),
+		first.Tick, last.Tick, time.Duration(last.Time-first.Time)*time.Millisecond, port)
+
+	srv := server.NewReplayServer(rec)
+	log.Fatal(srv.Start(port))
+}
+
+// overrideFlags collects repeated -set KEY=VALUE flags.
+type overrideFlags map[string]string
+
+func (o overrideFlags) String() string {
+	return fmt.Sprint(map[string]string(o))
+}
+
+func (o overrideFlags) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected KEY=VALUE, got %q", value)
+	}
+	o[key] = val
+	return nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/rand/v2"
+	"os"
+	"text/tabwriter"
+
+	"evo-sim/internal/entity"
+)
+
+// clock compares divergence times estimated from the neutral markers of
+// living creatures with the recorded phylogeny: for pairs of creatures, the
+// births down both lines of first parents since their common ancestor.
+// Founders count as sharing one, since their markers start equal.
+func clock(args []string) {
+	fs := flag.NewFlagSet("clock", flag.ExitOnError)
+	id := fs.Int64("id", 0, "snapshot ID (0 = latest)")
+	pairs := fs.Int("pairs", 1000, "creature pairs to sample")
+	rate := fs.Float64("rate", 0.5, "MARKER_MUTATION_RATE of the run")
+	store := openStore(fs, args)
+	defer store.Close()
+
+	snapshot := loadSnapshot(store, *id)
+
+	// Creatures whose line is recorded, with the position of each ancestor
+	// down it
+	var creatures []*entity.Creature
+	depth := make(map[int]map[int]int)
+	for _, c := range snapshot.Creatures {
+		chain, err := store.Ancestors(c.ID)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(chain) == 0 {
+			continue
+		}
+		d := make(map[int]int, len(chain))
+		for k, rec := range chain {
+			d[rec.CreatureID] = k
+		}
+		depth[c.ID] = d
+		creatures = append(creatures, c)
+	}
+	if len(creatures) < 2 {
+		log.Fatal("Fewer than two creatures of the snapshot have recorded lineage")
+	}
+
+	var same, different, all clockSummary
+	for n := 0; n < *pairs; n++ {
+		a := creatures[rand.IntN(len(creatures))]
+		b := creatures[rand.IntN(len(creatures))]
+		if a == b {
+			continue
+		}
+		recorded := divergence(depth[a.ID], depth[b.ID])
+		estimated := entity.MarkerDivergence(a.Genome, b.Genome, *rate)
+		all.add(recorded, estimated)
+		if a.SpeciesID == b.SpeciesID {
+			same.add(recorded, estimated)
+		} else {
+			different.add(recorded, estimated)
+		}
+	}
+
+	fmt.Printf("%d of %d creatures have recorded lineage\n", len(creatures), len(snapshot.Creatures))
+	fmt.Printf("Births since the common ancestor, both lines, recorded vs estimated from %d markers\n\n", entity.NumMarkers)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PAIRS\tCOUNT\tRECORDED\tESTIMATED\tCORRELATION")
+	same.print(tw, "same species")
+	different.print(tw, "different species")
+	all.print(tw, "all")
+	tw.Flush()
+}
+
+// divergence counts births from the nearest common ancestor of two lines,
+// given as ancestor depths, down to both ends. Lines without one meet above
+// their founders.
+func divergence(a, b map[int]int) int {
+	best := len(a) + len(b) - 2
+	for id, da := range a {
+		if db, ok := b[id]; ok && da+db < best {
+			best = da + db
+		}
+	}
+	return best
+}
+
+// clockSummary accumulates recorded and estimated divergences.
+type clockSummary struct {
+	n                     int
+	sx, sy, sxx, syy, sxy float64
+}
+
+func (s *clockSummary) add(recorded int, estimated float64) {
+	x := float64(recorded)
+	s.n++
+	s.sx += x
+	s.sy += estimated
+	s.sxx += x * x
+	s.syy += estimated * estimated
+	s.sxy += x * estimated
+}
+
+func (s *clockSummary) print(tw *tabwriter.Writer, label string) {
+	if s.n == 0 {
+		fmt.Fprintf(tw, "%s\t0\t-\t-\t-\n", label)
+		return
+	}
+	n := float64(s.n)
+	corr := "-"
+	vx, vy := s.sxx/n-(s.sx/n)*(s.sx/n), s.syy/n-(s.sy/n)*(s.sy/n)
+	if vx > 0 && vy > 0 {
+		corr = fmt.Sprintf("%.2f", (s.sxy/n-(s.sx/n)*(s.sy/n))/math.Sqrt(vx*vy))
+	}
+	fmt.Fprintf(tw, "%s\t%d\t%.1f\t%.1f\t%s\n", label, s.n, s.sx/n, s.sy/n, corr)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"evo-sim/internal/brain"
+	"evo-sim/internal/entity"
+)
+
+// code prints genome codes of a snapshot's creatures, one per line, ready
+// for SEED_GENOMES or spawn.
+func code(args []string) {
+	fs := flag.NewFlagSet("code", flag.ExitOnError)
+	id := fs.Int64("id", 0, "snapshot ID (0 = latest)")
+	creature := fs.Int("creature", 0, "creature ID (0 = all)")
+	withBrain := fs.Bool("brain", false, "include the brain")
+	store := openStore(fs, args)
+	defer store.Close()
+
+	snapshot := loadSnapshot(store, *id)
+	found := false
+	for _, c := range snapshot.Creatures {
+		if *creature != 0 && c.ID != *creature {
+			continue
+		}
+		found = true
+		var b brain.Brain
+		if *withBrain {
+			b = c.Brain
+		}
+		s, err := entity.EncodeCode(c.Genome, b)
+		if err != nil {
+			log.Fatalf("creature %d: %v", c.ID, err)
+		}
+		fmt.Println(s)
+	}
+	if *creature != 0 && !found {
+		log.Fatalf("creature %d isn't in the snapshot", *creature)
+	}
+}
+
+// spawn sends genome codes, from the arguments or one per line on stdin, to
+// a running simulation.
+func spawn(args []string) {
+	fs := flag.NewFlagSet("spawn", flag.ExitOnError)
+	url := fs.String("url", "http://localhost:8080", "simulation address")
+	count := fs.Int("count", 1, "copies of each creature")
+	fs.Parse(args)
+
+	codes := fs.Args()
+	if len(codes) == 0 {
+		sc := bufio.NewScanner(os.Stdin)
+		sc.Buffer(nil, 1<<20)
+		for sc.Scan() {
+			if line := strings.TrimSpace(sc.Text()); line != "" {
+				codes = append(codes, line)
+			}
+		}
+		if err := sc.Err(); err != nil {
+			log.Fatal(err)
+		}
+	}
+	for i, c := range codes {
+		if _, _, err := entity.DecodeCode(c); err != nil {
+			log.Fatalf("code %d: %v", i+1, err)
+		}
+	}
+
+	resp, err := http.Post(*url+"/api/spawn?count="+strconv.Itoa(*count), "text/plain", strings.NewReader(strings.Join(codes, "\n")))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		log.Fatalf("spawn failed: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	fmt.Print(string(body))
+}
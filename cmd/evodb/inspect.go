@@ -0,0 +1,404 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"evo-sim/internal/entity"
+	"evo-sim/internal/storage"
+	"evo-sim/internal/world"
+)
+
+// openStore opens the storage selected by the common -backend, -db and -run flags.
+func openStore(fs *flag.FlagSet, args []string) storage.Storage {
+	backend := fs.String("backend", storage.BackendSQLite, "storage backend: sqlite or dir")
+	dbPath := fs.String("db", "./database.db", "SQLite database or storage directory")
+	run := fs.String("run", storage.DefaultRun, "run ID")
+	fs.Parse(args)
+
+	store, err := storage.Open(*backend, *dbPath, storage.FormatJSON, *run)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return store
+}
+
+// runs prints every run and where forks branched off.
+func runs(args []string) {
+	fs := flag.NewFlagSet("runs", flag.ExitOnError)
+	store := openStore(fs, args)
+	defer store.Close()
+
+	list, err := store.ListRuns()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "RUN\tCREATED\tFORKED FROM\tOVERRIDES")
+	for _, run := range list {
+		origin := "-"
+		if run.ParentRun != "" {
+			origin = fmt.Sprintf("%s#%d", run.ParentRun, run.ParentSnapshot)
+		}
+		created := "-"
+		if !run.CreatedAt.IsZero() {
+			created = run.CreatedAt.Format(time.DateTime)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%v\n", run.ID, created, origin, run.Overrides)
+	}
+	tw.Flush()
+}
+
+// list prints every snapshot of a run with its stats.
+func list(args []string) {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	store := openStore(fs, args)
+	defer store.Close()
+
+	infos, err := store.ListSnapshots()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tCREATED\tSIZE\tCREATURES\tFOOD\tSPECIES\tCARNIVORES\tMAX GEN")
+	for _, info := range infos {
+		snapshot, err := store.LoadSnapshot(info.ID)
+		if err != nil {
+			fmt.Fprintf(tw, "%d\t%s\t%d\terror: %v\n", info.ID, info.CreatedAt.Format(time.DateTime), info.Size, err)
+			continue
+		}
+		sum := summarize(snapshot)
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%d\t%d\t%d\t%d\n",
+			info.ID, info.CreatedAt.Format(time.DateTime), info.Size,
+			len(snapshot.Creatures), len(snapshot.Food), len(sum.species), sum.carnivores, sum.maxGeneration)
+	}
+	tw.Flush()
+}
+
+// show prints population and species summaries of one snapshot.
+func show(args []string) {
+	fs := flag.NewFlagSet("show", flag.ExitOnError)
+	id := fs.Int64("id", 0, "snapshot ID (0 = latest)")
+	store := openStore(fs, args)
+	defer store.Close()
+
+	snapshot := loadSnapshot(store, *id)
+	sum := summarize(snapshot)
+
+	fmt.Printf("Snapshot taken %s\n", time.Unix(snapshot.Timestamp, 0).Format(time.DateTime))
+	fmt.Printf("Creatures: %d (carnivores %d), food: %d (carrion %d)\n",
+		len(snapshot.Creatures), sum.carnivores, len(snapshot.Food), sum.carrion)
+	fmt.Printf("Mean energy %.1f, mean age %.0f, max generation %d\n",
+		sum.meanEnergy, sum.meanAge, sum.maxGeneration)
+	fmt.Printf("Linkage disequilibrium (mean r²): %.3f linked, %.3f unlinked\n\n",
+		sum.linkedLD, sum.unlinkedLD)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SPECIES\tCOUNT\tCARNIVORES\tSIZE\tSPEED\tSENSE\tDIET\tMETABOLISM\tHIDDEN\tMAX GEN")
+	for _, sp := range sum.sortedSpecies() {
+		n := float64(sp.count)
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%.2f\t%.2f\t%.0f\t%.2f\t%.2f\t%.1f\t%d\n",
+			sp.id, sp.count, sp.carnivores,
+			sp.size/n, sp.speed/n, sp.sense/n, sp.diet/n, sp.metabolism/n, sp.hidden/n, sp.maxGeneration)
+	}
+	tw.Flush()
+}
+
+// export writes creatures or food of one snapshot as CSV or NDJSON.
+func export(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	id := fs.Int64("id", 0, "snapshot ID (0 = latest)")
+	what := fs.String("what", "creatures", "creatures or food")
+	format := fs.String("format", "csv", "csv or ndjson")
+	out := fs.String("o", "-", "output file (- = stdout)")
+	store := openStore(fs, args)
+	defer store.Close()
+
+	snapshot := loadSnapshot(store, *id)
+
+	var rows []record
+	switch *what {
+	case "creatures":
+		for _, c := range snapshot.Creatures {
+			rows = append(rows, creatureRecord(c))
+		}
+	case "food":
+		for _, f := range snapshot.Food {
+			rows = append(rows, foodRecord(f))
+		}
+	default:
+		log.Fatalf("unknown -what %q", *what)
+	}
+
+	var write func(io.Writer, []record) error
+	switch *format {
+	case "csv":
+		write = writeCSV
+	case "ndjson":
+		write = writeNDJSON
+	default:
+		log.Fatalf("unknown -format %q", *format)
+	}
+
+	if err := writeExport(*out, write, rows); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// writeExport writes rows to path, or stdout for "-", flushing and closing
+// the output even if writing fails.
+func writeExport(path string, write func(io.Writer, []record) error, rows []record) (err error) {
+	var w io.Writer = os.Stdout
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}()
+		w = f
+	}
+
+	bw := bufio.NewWriter(w)
+	err = write(bw, rows)
+	if ferr := bw.Flush(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+func loadSnapshot(store storage.Storage, id int64) *storage.WorldSnapshot {
+	if id == 0 {
+		infos, err := store.ListSnapshots()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(infos) == 0 {
+			log.Fatal("no snapshots stored")
+		}
+		id = infos[len(infos)-1].ID
+	}
+
+	snapshot, err := store.LoadSnapshot(id)
+	if err != nil {
+		log.Fatalf("load snapshot %d: %v", id, err)
+	}
+	return snapshot
+}
+
+type speciesSummary struct {
+	id, count, carnivores, maxGeneration         int
+	size, speed, sense, diet, metabolism, hidden float64
+}
+
+type snapshotSummary struct {
+	carnivores, carrion, maxGeneration int
+	meanEnergy, meanAge                float64
+	linkedLD, unlinkedLD               float64
+	species                            map[int]*speciesSummary
+}
+
+func summarize(snapshot *storage.WorldSnapshot) snapshotSummary {
+	sum := snapshotSummary{species: make(map[int]*speciesSummary)}
+
+	genomes := make([]entity.Genome, 0, len(snapshot.Creatures))
+	for _, c := range snapshot.Creatures {
+		genomes = append(genomes, c.Genome)
+		sp, ok := sum.species[c.SpeciesID]
+		if !ok {
+			sp = &speciesSummary{id: c.SpeciesID}
+			sum.species[c.SpeciesID] = sp
+		}
+		sp.count++
+		if c.IsCarnivore {
+			sp.carnivores++
+			sum.carnivores++
+		}
+		sp.maxGeneration = max(sp.maxGeneration, c.Generation)
+		sum.maxGeneration = max(sum.maxGeneration, c.Generation)
+		sp.size += c.Genome.ExpressedSize()
+		sp.speed += c.Genome.ExpressedSpeed()
+		sp.sense += c.Genome.ExpressedSense()
+		sp.diet += c.Genome.ExpressedDiet()
+		sp.metabolism += c.Genome.ExpressedMetabolism()
+		sp.hidden += c.Genome.ExpressedHidden()
+		sum.meanEnergy += c.Energy
+		sum.meanAge += float64(c.Age)
+	}
+	if n := float64(len(snapshot.Creatures)); n > 0 {
+		sum.meanEnergy /= n
+		sum.meanAge /= n
+	}
+	sum.linkedLD, sum.unlinkedLD = entity.MeanLinkageDisequilibrium(genomes)
+
+	for _, f := range snapshot.Food {
+		if f.Energy > 0 {
+			sum.carrion++
+		}
+	}
+	return sum
+}
+
+// sortedSpecies orders species by population, largest first.
+func (s snapshotSummary) sortedSpecies() []*speciesSummary {
+	list := make([]*speciesSummary, 0, len(s.species))
+	for _, sp := range s.species {
+		list = append(list, sp)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].count != list[j].count {
+			return list[i].count > list[j].count
+		}
+		return list[i].id < list[j].id
+	})
+	return list
+}
+
+// record is one exported row with ordered columns.
+type record struct {
+	keys   []string
+	values []any
+}
+
+func (r *record) add(key string, value any) {
+	r.keys = append(r.keys, key)
+	r.values = append(r.values, value)
+}
+
+func creatureRecord(c *entity.Creature) record {
+	var r record
+	r.add("id", c.ID)
+	r.add("species_id", c.SpeciesID)
+	r.add("generation", c.Generation)
+	r.add("x", c.X)
+	r.add("y", c.Y)
+	r.add("energy", c.Energy)
+	r.add("age", c.Age)
+
+	// Derived stats
+	r.add("size", c.Size)
+	r.add("mass", c.Mass)
+	r.add("speed", c.Speed)
+	r.add("view_radius", c.ViewRadius)
+	r.add("bmr", c.BMR)
+	r.add("max_energy", c.MaxEnergy)
+	r.add("reproduction_threshold", c.ReproductionThreshold)
+	r.add("is_carnivore", c.IsCarnivore)
+
+	// Expressed traits
+	g := c.Genome
+	for i, l := range entity.Loci {
+		if !l.Haploid {
+			r.add("expr_"+l.Name, g.Express(i))
+		}
+	}
+
+	// Raw genome
+	for i, l := range entity.Loci {
+		if l.Haploid {
+			r.add(l.Name, g.Alleles[i][0])
+			continue
+		}
+		r.add(l.Name+"_a1", g.Alleles[i][0])
+		r.add(l.Name+"_a2", g.Alleles[i][1])
+	}
+
+	brainType, hidden := "", 0
+	if c.Brain != nil {
+		brainType = c.Brain.Type()
+		_, hidden, _ = c.Brain.Shape()
+	}
+	r.add("brain_type", brainType)
+	r.add("brain_hidden", hidden)
+	r.add("sensors", world.GenomeSensors(g))
+	return r
+}
+
+func foodRecord(f entity.Food) record {
+	var r record
+	r.add("id", f.ID)
+	r.add("x", f.X)
+	r.add("y", f.Y)
+	r.add("energy", f.Energy)
+	r.add("decay_ticks", f.DecayTicks)
+	r.add("is_carrion", f.Energy > 0)
+	return r
+}
+
+func writeCSV(w io.Writer, rows []record) error {
+	cw := csv.NewWriter(w)
+	for i, r := range rows {
+		if i == 0 {
+			if err := cw.Write(r.keys); err != nil {
+				return err
+			}
+		}
+		fields := make([]string, len(r.values))
+		for j, v := range r.values {
+			fields[j] = formatValue(v)
+		}
+		if err := cw.Write(fields); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// writeNDJSON writes one JSON object per row, keeping column order.
+func writeNDJSON(w io.Writer, rows []record) error {
+	for _, r := range rows {
+		buf := []byte{'{'}
+		for i, k := range r.keys {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			key, _ := json.Marshal(k)
+			v := r.values[i]
+			if f, ok := v.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+				v = nil // JSON has no NaN or infinities
+			}
+			val, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			buf = append(buf, key...)
+			buf = append(buf, ':')
+			buf = append(buf, val...)
+		}
+		buf = append(buf, '}', '\n')
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
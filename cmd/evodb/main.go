@@ -0,0 +1,141 @@
+// Command evodb inspects and maintains simulation databases offline, and
+// spawns creatures from genome codes into a running simulation.
+//
+// Usage:
+//
+//	evodb runs [-backend sqlite|dir] [-db path]
+//	evodb list [-run ID] [-backend sqlite|dir] [-db path]
+//	evodb show [-id N] [-run ID] [-backend sqlite|dir] [-db path]
+//	evodb clock [-id N] [-pairs N] [-rate R] [-run ID] [-backend sqlite|dir] [-db path]
+//	evodb code [-id N] [-creature ID] [-brain] [-run ID] [-backend sqlite|dir] [-db path]
+//	evodb spawn [-url http://host:port] [-count N] [code...]
+//	evodb export [-id N] [-what creatures|food] [-format csv|ndjson] [-o file] [-run ID] [-backend sqlite|dir] [-db path]
+//	evodb compact [-db path] [-format json|binary] [-hourly 24h] [-daily 720h] [-weekly 0]
+//	evodb migrate [-db path]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"evo-sim/internal/storage"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	switch os.Args[1] {
+	case "runs":
+		runs(os.Args[2:])
+	case "list":
+		list(os.Args[2:])
+	case "show":
+		show(os.Args[2:])
+	case "clock":
+		clock(os.Args[2:])
+	case "code":
+		code(os.Args[2:])
+	case "spawn":
+		spawn(os.Args[2:])
+	case "export":
+		export(os.Args[2:])
+	case "compact":
+		compact(os.Args[2:])
+	case "migrate":
+		migrate(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: evodb runs|list|show|clock|code|spawn|export|compact|migrate [flags]")
+	os.Exit(2)
+}
+
+// compact applies the retention policy, optionally re-encodes the remaining
+// snapshots and vacuums the database file.
+func compact(args []string) {
+	fs := flag.NewFlagSet("compact", flag.ExitOnError)
+	dbPath := fs.String("db", "./database.db", "path to the SQLite database")
+	format := fs.String("format", "", "re-encode snapshots as json or binary (empty keeps them as is)")
+	hourly := fs.Duration("hourly", 24*time.Hour, "keep one snapshot per hour for this long")
+	daily := fs.Duration("daily", 30*24*time.Hour, "then one per day for this long")
+	weekly := fs.Duration("weekly", 0, "then one per week for this long (0 = forever)")
+	fs.Parse(args)
+
+	store, err := storage.NewSQLiteStorage(*dbPath, storage.FormatJSON, storage.DefaultRun)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer store.Close()
+
+	before := fileSize(*dbPath)
+
+	runs, err := store.ListRuns()
+	if err != nil {
+		log.Fatal(err)
+	}
+	policy := storage.RetentionPolicy{Hourly: *hourly, Daily: *daily, Weekly: *weekly}
+	for _, run := range runs {
+		runStore := &storage.SQLiteStorage{DB: store.DB, RunID: run.ID}
+		pruned, err := storage.Prune(runStore, policy)
+		if err != nil {
+			log.Fatalf("Prune of run %q failed: %v", run.ID, err)
+		}
+		log.Printf("Pruned %d snapshots of run %q", pruned, run.ID)
+	}
+
+	if *format != "" {
+		n, err := store.Reencode(*format)
+		if err != nil {
+			log.Fatal("Re-encode failed: ", err)
+		}
+		log.Printf("Re-encoded %d snapshots as %s", n, *format)
+	}
+
+	if err := store.Compact(); err != nil {
+		log.Fatal("VACUUM failed: ", err)
+	}
+	log.Printf("Database size: %d -> %d bytes", before, fileSize(*dbPath))
+}
+
+// migrate upgrades the schema and rewrites old JSON snapshots in the current
+// creature layout.
+func migrate(args []string) {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dbPath := fs.String("db", "./database.db", "path to the SQLite database")
+	fs.Parse(args)
+
+	// Opening the storage applies pending schema migrations
+	store, err := storage.NewSQLiteStorage(*dbPath, storage.FormatJSON, storage.DefaultRun)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer store.Close()
+
+	version, err := storage.SchemaVersion(store.DB)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Schema version: %d", version)
+
+	n, err := store.UpgradeSnapshots()
+	if err != nil {
+		log.Fatal("Snapshot upgrade failed: ", err)
+	}
+	log.Printf("Upgraded %d snapshots to layout v%d", n, storage.CurrentSnapshotVersion)
+}
+
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
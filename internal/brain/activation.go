@@ -0,0 +1,90 @@
+package brain
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+)
+
+// Activation is a neuron's transfer function.
+type Activation uint8
+
+const (
+	Tanh Activation = iota
+	ReLU
+	Sigmoid
+	Step
+	Gaussian
+)
+
+var activationNames = [...]string{"tanh", "relu", "sigmoid", "step", "gaussian"}
+
+// Choices offered by mutation. Outputs drive movement directly, so they
+// skip the unbounded ReLU.
+var (
+	hiddenActivations = []Activation{Tanh, ReLU, Sigmoid, Step, Gaussian}
+	outputActivations = []Activation{Tanh, Sigmoid, Step, Gaussian}
+)
+
+// activationMutationRate is the chance, relative to the mutation rate, that a
+// neuron switches to another activation function.
+const activationMutationRate = 0.1
+
+func (a Activation) Apply(x float64) float64 {
+	switch a {
+	case ReLU:
+		return math.Max(0, x)
+	case Sigmoid:
+		return 1.0 / (1.0 + math.Exp(-x))
+	case Step:
+		if x > 0 {
+			return 1
+		}
+		return 0
+	case Gaussian:
+		return math.Exp(-x * x)
+	default:
+		return math.Tanh(x)
+	}
+}
+
+func (a Activation) String() string {
+	if int(a) < len(activationNames) {
+		return activationNames[a]
+	}
+	return fmt.Sprintf("Activation(%d)", a)
+}
+
+func (a Activation) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+func (a *Activation) UnmarshalText(text []byte) error {
+	for i, name := range activationNames {
+		if name == string(text) {
+			*a = Activation(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("brain: unknown activation %q", text)
+}
+
+func crossoverActivations(a, b []Activation) []Activation {
+	result := make([]Activation, len(a))
+	for i := range a {
+		if rand.Float64() < 0.5 {
+			result[i] = a[i]
+		} else {
+			result[i] = b[i]
+		}
+	}
+	return result
+}
+
+func mutateActivations(acts []Activation, rate float64, choices []Activation) {
+	for i := range acts {
+		if rand.Float64() < rate {
+			acts[i] = choices[rand.IntN(len(choices))]
+		}
+	}
+}
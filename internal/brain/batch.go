@@ -0,0 +1,258 @@
+package brain
+
+import "math"
+
+// Batch evaluates the brains of a whole population at once. Elman networks
+// are grouped by shape and run over contiguous float32 copies of their
+// weights, which are only repacked when a group's members or their weights
+// change. Other brain types fall back to FeedForward.
+//
+// A Batch reuses its buffers, so evaluating a steady population allocates
+// nothing per tick. Use it as Reset, Add for every brain, Run, then Output.
+type Batch struct {
+	groups  []*batchGroup
+	entries []batchEntry
+}
+
+type batchEntry struct {
+	brain  Brain
+	inputs []float64 // Only kept for brains that aren't batched
+	output []float64
+	group  *batchGroup
+	slot   int
+}
+
+// batchGroup holds networks of one shape in tiles of batchLanes members.
+// A tile stores each parameter as a vector with one lane per member, so the
+// inner loops run across members over contiguous memory.
+type batchGroup struct {
+	input, hidden, output int
+
+	nets      []*Network
+	revisions []uint32
+	count     int // Members added since Reset
+
+	// Indexed by tile*size + i, where size is the per-member count
+	w1, w2 []lanes
+	b1, b2 []lanes
+	a1, a2 [][batchLanes]Activation
+	x      []lanes // Sensory inputs followed by the previous hidden state
+	h      []lanes
+	y      []lanes
+}
+
+const batchLanes = 8
+
+type lanes [batchLanes]float32
+
+func NewBatch() *Batch {
+	return &Batch{}
+}
+
+// Reset starts a new tick.
+func (b *Batch) Reset() {
+	b.entries = b.entries[:0]
+	for _, g := range b.groups {
+		g.count = 0
+	}
+}
+
+// Add queues a brain with its inputs and returns its index for Output. The
+// inputs must not change before Run.
+func (b *Batch) Add(br Brain, inputs []float64) int {
+	e := batchEntry{brain: br}
+	if nn, ok := br.(*Network); ok {
+		e.group = b.group(nn.InputSize, nn.HiddenSize, nn.OutputSize)
+		e.slot = e.group.add(nn, inputs)
+	} else {
+		e.inputs = inputs
+	}
+	b.entries = append(b.entries, e)
+	return len(b.entries) - 1
+}
+
+// Run evaluates every brain added since Reset.
+func (b *Batch) Run() {
+	for _, g := range b.groups {
+		g.run()
+	}
+	for i := range b.entries {
+		e := &b.entries[i]
+		if e.group != nil {
+			e.output = e.group.nets[e.slot].outputBuffer
+		} else {
+			e.output = e.brain.FeedForward(e.inputs)
+		}
+	}
+}
+
+// Output returns the outputs of the brain at index i after Run. Like
+// FeedForward's result, the slice belongs to the brain.
+func (b *Batch) Output(i int) []float64 {
+	return b.entries[i].output
+}
+
+func (b *Batch) group(input, hidden, output int) *batchGroup {
+	for _, g := range b.groups {
+		if g.input == input && g.hidden == hidden && g.output == output {
+			return g
+		}
+	}
+	g := &batchGroup{input: input, hidden: hidden, output: output}
+	b.groups = append(b.groups, g)
+	return g
+}
+
+// add places nn in the next slot, repacking its parameters unless the slot
+// already holds them, and loads its inputs and recurrent state.
+func (g *batchGroup) add(nn *Network, inputs []float64) int {
+	m := g.count
+	g.count++
+	if m == len(g.nets) {
+		g.grow()
+	}
+	if g.nets[m] != nn || g.revisions[m] != nn.revision {
+		g.pack(m, nn)
+	}
+
+	in := g.input + g.hidden
+	t, l := m/batchLanes, m%batchLanes
+	x := g.x[t*in : (t+1)*in]
+	for i, v := range inputs[:g.input] {
+		x[i][l] = float32(v)
+	}
+	for i, v := range nn.hiddenState {
+		x[g.input+i][l] = float32(v)
+	}
+	if nn.lastInput == nil {
+		nn.lastInput = make([]float64, nn.InputSize)
+	}
+	copy(nn.lastInput, inputs)
+	return m
+}
+
+// grow adds a slot, and a tile when the last one is full.
+func (g *batchGroup) grow() {
+	g.nets = append(g.nets, nil)
+	g.revisions = append(g.revisions, 0)
+	if (len(g.nets)-1)%batchLanes != 0 {
+		return
+	}
+	in := g.input + g.hidden
+	g.w1 = append(g.w1, make([]lanes, in*g.hidden)...)
+	g.w2 = append(g.w2, make([]lanes, g.hidden*g.output)...)
+	g.b1 = append(g.b1, make([]lanes, g.hidden)...)
+	g.b2 = append(g.b2, make([]lanes, g.output)...)
+	g.a1 = append(g.a1, make([][batchLanes]Activation, g.hidden)...)
+	g.a2 = append(g.a2, make([][batchLanes]Activation, g.output)...)
+	g.x = append(g.x, make([]lanes, in)...)
+	g.h = append(g.h, make([]lanes, g.hidden)...)
+	g.y = append(g.y, make([]lanes, g.output)...)
+}
+
+func (g *batchGroup) pack(m int, nn *Network) {
+	g.nets[m] = nn
+	g.revisions[m] = nn.revision
+	t, l := m/batchLanes, m%batchLanes
+	for i, v := range nn.weights1 {
+		g.w1[t*len(nn.weights1)+i][l] = float32(v)
+	}
+	for i, v := range nn.weights2 {
+		g.w2[t*len(nn.weights2)+i][l] = float32(v)
+	}
+	for i := range g.hidden {
+		g.b1[t*g.hidden+i][l] = float32(nn.bias1[i])
+		g.a1[t*g.hidden+i][l] = nn.act1[i]
+	}
+	for i := range g.output {
+		g.b2[t*g.output+i][l] = float32(nn.bias2[i])
+		g.a2[t*g.output+i][l] = nn.act2[i]
+	}
+}
+
+// run evaluates the members like Network.FeedForward and writes the hidden
+// state and outputs back to each network.
+func (g *batchGroup) run() {
+	in := g.input + g.hidden
+	n1, n2 := in*g.hidden, g.hidden*g.output
+	tiles := (g.count + batchLanes - 1) / batchLanes
+	for t := range tiles {
+		h := g.h[t*g.hidden : (t+1)*g.hidden]
+		dense32(h, g.x[t*in:(t+1)*in], g.w1[t*n1:(t+1)*n1], g.b1[t*g.hidden:], g.a1[t*g.hidden:])
+		dense32(g.y[t*g.output:(t+1)*g.output], h, g.w2[t*n2:(t+1)*n2], g.b2[t*g.output:], g.a2[t*g.output:])
+	}
+
+	for m, nn := range g.nets[:g.count] {
+		t, l := m/batchLanes, m%batchLanes
+		for i := range nn.hiddenBuffer {
+			nn.hiddenBuffer[i] = float64(g.h[t*g.hidden+i][l])
+		}
+		copy(nn.hiddenState, nn.hiddenBuffer)
+		for i := range nn.outputBuffer {
+			nn.outputBuffer[i] = float64(g.y[t*g.output+i][l])
+		}
+	}
+	// Don't keep the networks of dead creatures alive
+	clear(g.nets[g.count:])
+}
+
+// dense32 computes out = act(bias + x·w) for one tile, where w holds a row
+// per input.
+func dense32(out, x, w, bias []lanes, act [][batchLanes]Activation) {
+	n := len(out)
+	for i := range out {
+		sum := bias[i]
+		for j := range x {
+			xj, wij := &x[j], &w[j*n+i]
+			for l := range sum {
+				sum[l] += xj[l] * wij[l]
+			}
+		}
+		for l, v := range sum {
+			out[i][l] = act[i][l].apply32(v)
+		}
+	}
+}
+
+// apply32 is Apply in single precision, with exp32 instead of math.Exp.
+func (a Activation) apply32(x float32) float32 {
+	switch a {
+	case ReLU:
+		return max(0, x)
+	case Sigmoid:
+		return 1 / (1 + exp32(-x))
+	case Step:
+		if x > 0 {
+			return 1
+		}
+		return 0
+	case Gaussian:
+		return exp32(-x * x)
+	default:
+		return tanh32(x)
+	}
+}
+
+func tanh32(x float32) float32 {
+	// Saturates correctly: exp32 is clamped, so the division never sees Inf
+	return 1 - 2/(1+exp32(2*x))
+}
+
+// exp32 reduces x to 2^k * e^r with |r| <= ln2/2 and approximates e^r with a
+// degree 6 polynomial. The relative error is a few float32 ulps.
+func exp32(x float32) float32 {
+	const (
+		log2e = 1.44269504
+		ln2Hi = 0.693359375
+		ln2Lo = -2.12194440e-4
+	)
+	x = max(-87, min(88, x))
+	n := x*log2e + 0.5
+	k := int32(n)
+	if n < float32(k) {
+		k-- // Round down, the conversion truncates towards zero
+	}
+	r := x - float32(k)*ln2Hi - float32(k)*ln2Lo
+	p := 1 + r*(1+r*(1.0/2+r*(1.0/6+r*(1.0/24+r*(1.0/120+r*(1.0/720))))))
+	return p * math.Float32frombits(uint32(k+127)<<23)
+}
@@ -0,0 +1,118 @@
+package brain
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// population returns networks of mixed hidden sizes with evolved-looking
+// biases and activations, plus an input vector for each.
+func population(n int) ([]*Network, [][]float64) {
+	nets := make([]*Network, n)
+	inputs := make([][]float64, n)
+	for i := range nets {
+		nets[i] = NewNetwork(11, 3+i%10, 2)
+		nets[i].Mutate(0.5, 0.5)
+		inputs[i] = make([]float64, 11)
+		for j := range inputs[i] {
+			inputs[i][j] = rand.Float64()*2 - 1
+		}
+	}
+	return nets, inputs
+}
+
+func TestBatch_MatchesFeedForward(t *testing.T) {
+	nets, inputs := population(50)
+	clones := make([]*Network, len(nets))
+	for i, nn := range nets {
+		clones[i] = nn.Clone()
+	}
+
+	batch := NewBatch()
+	for tick := 0; tick < 5; tick++ {
+		batch.Reset()
+		for i, nn := range nets {
+			batch.Add(nn, inputs[i])
+		}
+		batch.Run()
+
+		for i, clone := range clones {
+			want := clone.FeedForward(inputs[i])
+			got := batch.Output(i)
+			for k := range want {
+				if math.Abs(got[k]-want[k]) > 1e-4 {
+					t.Fatalf("tick %d net %d output %d: batch %f, FeedForward %f", tick, i, k, got[k], want[k])
+				}
+			}
+		}
+	}
+}
+
+func TestBatch_RepacksChangedWeights(t *testing.T) {
+	nn := NewNetwork(2, 3, 1)
+	inputs := []float64{1, 1}
+	batch := NewBatch()
+	batch.Add(nn, inputs)
+	batch.Run()
+
+	fill(nn.weights2, 0)
+	nn.Mutate(0, 0) // Marks the weights as changed
+	nn.hiddenState = make([]float64, 3)
+	batch.Reset()
+	batch.Add(nn, inputs)
+	batch.Run()
+	if out := batch.Output(0)[0]; out != 0 {
+		t.Errorf("output %f with zeroed output weights, batch used stale weights", out)
+	}
+}
+
+func TestActivation_Apply32CloseToApply(t *testing.T) {
+	for _, a := range hiddenActivations {
+		for x := -20.0; x <= 20; x += 0.01 {
+			if d := math.Abs(float64(a.apply32(float32(x))) - a.Apply(x)); d > 1e-6 {
+				t.Fatalf("%s(%f): apply32 is off by %g", a, x, d)
+			}
+		}
+	}
+}
+
+func TestBatch_NoAllocationsPerTick(t *testing.T) {
+	nets, inputs := population(200)
+	batch := NewBatch()
+	tick := func() {
+		batch.Reset()
+		for i, nn := range nets {
+			batch.Add(nn, inputs[i])
+		}
+		batch.Run()
+	}
+	tick()
+
+	if allocs := testing.AllocsPerRun(10, tick); allocs != 0 {
+		t.Errorf("%v allocations per tick, want 0", allocs)
+	}
+}
+
+func BenchmarkFeedForward(b *testing.B) {
+	nets, inputs := population(2000)
+	b.ReportAllocs()
+	for b.Loop() {
+		for i, nn := range nets {
+			nn.FeedForward(inputs[i])
+		}
+	}
+}
+
+func BenchmarkBatch(b *testing.B) {
+	nets, inputs := population(2000)
+	batch := NewBatch()
+	b.ReportAllocs()
+	for b.Loop() {
+		batch.Reset()
+		for i, nn := range nets {
+			batch.Add(nn, inputs[i])
+		}
+		batch.Run()
+	}
+}
@@ -0,0 +1,121 @@
+package brain
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+)
+
+// Brain types selectable with the BRAIN_TYPE setting.
+const (
+	TypeElman = "elman" // Fixed-topology Network
+	TypeNEAT  = "neat"  // Topology-evolving NEAT network
+	TypeDeep  = "deep"  // Multi-layer DeepNetwork
+)
+
+// Spec selects a brain architecture.
+type Spec struct {
+	Type  string
+	Depth int  // Hidden layers of deep brains
+	Gated bool // Deep brains: GRU memory instead of Elman context
+}
+
+// Layers is the number of hidden layer sizes New expects.
+func (s Spec) Layers() int {
+	if s.Type == TypeDeep && s.Depth > 1 {
+		return s.Depth
+	}
+	return 1
+}
+
+// Brain maps a creature's sensor inputs to motor outputs.
+type Brain interface {
+	FeedForward(inputs []float64) []float64
+	// Offspring returns a child brain with the given hidden layer sizes: a
+	// copy of this brain, or a crossover with mate if mate is non-nil and
+	// compatible. layers has one entry per Layers().
+	Offspring(mate Brain, layers []int) Brain
+	Mutate(rate, strength float64)
+	// Shape reports the sensor, total hidden neuron and motor counts.
+	Shape() (input, hidden, output int)
+	Layers() int
+	Type() string
+	// Graph describes the structure and the activity of the last tick.
+	Graph() Graph
+
+	json.Marshaler
+	encoding.BinaryMarshaler
+}
+
+// New creates a random brain with spec.Layers() hidden layer sizes. Unknown
+// types get a Network; check settings with KnownType first.
+func New(spec Spec, input int, layers []int, output int) Brain {
+	switch spec.Type {
+	case TypeNEAT:
+		return NewNEAT(input, layers[0], output)
+	case TypeDeep:
+		return NewDeepNetwork(input, layers, output, spec.Gated)
+	default:
+		return NewNetwork(input, layers[0], output)
+	}
+}
+
+func KnownType(brainType string) bool {
+	switch brainType {
+	case TypeElman, TypeNEAT, TypeDeep:
+		return true
+	}
+	return false
+}
+
+// DecodeBinary restores a brain written by its MarshalBinary. The first byte
+// tells the types apart: Network encodings start with their small version
+// number, other types with a letter tag.
+func DecodeBinary(data []byte) (Brain, error) {
+	if len(data) == 0 {
+		return nil, errShortBuffer
+	}
+	var b interface {
+		Brain
+		encoding.BinaryUnmarshaler
+	}
+	switch data[0] {
+	case neatEncodingTag:
+		b = &NEAT{}
+	case deepEncodingTag:
+		b = &DeepNetwork{}
+	default:
+		b = &Network{}
+	}
+	if err := b.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// DecodeJSON restores a brain written by its MarshalJSON. Networks carry no
+// type field, as in snapshots taken before other brain types existed.
+func DecodeJSON(data []byte) (Brain, error) {
+	var tag struct{ Type string }
+	if err := json.Unmarshal(data, &tag); err != nil {
+		return nil, err
+	}
+	var b interface {
+		Brain
+		json.Unmarshaler
+	}
+	switch tag.Type {
+	case TypeNEAT:
+		b = &NEAT{}
+	case TypeDeep:
+		b = &DeepNetwork{}
+	case TypeElman, "":
+		b = &Network{}
+	default:
+		return nil, fmt.Errorf("brain: unknown type %q", tag.Type)
+	}
+	if err := b.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
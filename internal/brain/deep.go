@@ -0,0 +1,274 @@
+package brain
+
+import (
+	"math"
+	"math/rand/v2"
+)
+
+// DeepNetwork stacks several hidden layers. The first hidden layer carries
+// memory between ticks: Elman-style context like Network, or a GRU cell when
+// Gated. Later layers and the output layer are plain dense tanh layers.
+type DeepNetwork struct {
+	InputSize  int
+	OutputSize int
+	Sizes      []int // Hidden layer sizes, input side first
+	Gated      bool
+
+	// Per hidden layer: one dense layer, or for a gated first layer the GRU's
+	// update, reset and candidate layers
+	hidden [][]*dense
+	output *dense
+
+	state        []float64   // Memory of the first hidden layer
+	activations  [][]float64 // Per hidden layer
+	outputBuffer []float64
+	gates        []float64 // GRU scratch: update gate, reset gate, reset*state
+}
+
+// GRU gate order in hidden[0].
+const (
+	gateUpdate = iota
+	gateReset
+	gateCandidate
+)
+
+// dense is a fully connected layer. Its input rows are the external inputs
+// followed by context (recurrent) inputs; weights use the same layout as
+// Network: row*Out + column.
+type dense struct {
+	External, Context, Out int
+	W, B                   []float64
+}
+
+func newDense(external, context, out int) *dense {
+	return &dense{
+		External: external,
+		Context:  context,
+		Out:      out,
+		W:        initWeights((external + context) * out),
+		B:        make([]float64, out),
+	}
+}
+
+func (d *dense) apply(ext, ctx, out []float64, act func(float64) float64) {
+	for i := 0; i < d.Out; i++ {
+		sum := d.B[i]
+		for j := 0; j < d.External; j++ {
+			sum += ext[j] * d.W[j*d.Out+i]
+		}
+		for k := 0; k < d.Context; k++ {
+			sum += ctx[k] * d.W[(d.External+k)*d.Out+i]
+		}
+		out[i] = act(sum)
+	}
+}
+
+// weight returns the value a parent layer holds for row j, column i of a
+// layer shaped like d, if the parent has a corresponding weight.
+func (d *dense) weight(parent *dense, j, i int) (float64, bool) {
+	if parent == nil || i >= parent.Out {
+		return 0, false
+	}
+	if j < d.External {
+		if j >= parent.External {
+			return 0, false
+		}
+		return parent.W[j*parent.Out+i], true
+	}
+	k := j - d.External
+	if k >= parent.Context {
+		return 0, false
+	}
+	return parent.W[(parent.External+k)*parent.Out+i], true
+}
+
+// inherit fills d from up to two parent layers of possibly different shape,
+// the way CrossoverWithResize does for Network: weights both parents have
+// are picked at random, weights only one has are copied, the rest keep
+// their random initialization.
+func (d *dense) inherit(a, b *dense) {
+	pick := func(dst *float64, va float64, okA bool, vb float64, okB bool) {
+		switch {
+		case okA && okB:
+			if rand.Float64() < 0.5 {
+				*dst = va
+			} else {
+				*dst = vb
+			}
+		case okA:
+			*dst = va
+		case okB:
+			*dst = vb
+		}
+	}
+
+	for j := 0; j < d.External+d.Context; j++ {
+		for i := 0; i < d.Out; i++ {
+			va, okA := d.weight(a, j, i)
+			vb, okB := d.weight(b, j, i)
+			pick(&d.W[j*d.Out+i], va, okA, vb, okB)
+		}
+	}
+	for i := 0; i < d.Out; i++ {
+		var va, vb float64
+		okA := a != nil && i < a.Out
+		okB := b != nil && i < b.Out
+		if okA {
+			va = a.B[i]
+		}
+		if okB {
+			vb = b.B[i]
+		}
+		pick(&d.B[i], va, okA, vb, okB)
+	}
+}
+
+func NewDeepNetwork(input int, sizes []int, output int, gated bool) *DeepNetwork {
+	nn := &DeepNetwork{
+		InputSize:  input,
+		OutputSize: output,
+		Sizes:      append([]int(nil), sizes...),
+		Gated:      gated,
+	}
+
+	external := input
+	for k, size := range sizes {
+		switch {
+		case k == 0 && gated:
+			nn.hidden = append(nn.hidden, []*dense{
+				newDense(external, size, size),
+				newDense(external, size, size),
+				newDense(external, size, size),
+			})
+		case k == 0:
+			nn.hidden = append(nn.hidden, []*dense{newDense(external, size, size)})
+		default:
+			nn.hidden = append(nn.hidden, []*dense{newDense(external, 0, size)})
+		}
+		external = size
+	}
+	nn.output = newDense(external, 0, output)
+
+	nn.allocate()
+	return nn
+}
+
+// allocate creates the runtime buffers. Memory starts zeroed.
+func (nn *DeepNetwork) allocate() {
+	nn.activations = make([][]float64, len(nn.Sizes))
+	for k, size := range nn.Sizes {
+		nn.activations[k] = make([]float64, size)
+	}
+	nn.outputBuffer = make([]float64, nn.OutputSize)
+	if len(nn.Sizes) > 0 {
+		nn.state = make([]float64, nn.Sizes[0])
+		nn.gates = make([]float64, 3*nn.Sizes[0])
+	}
+}
+
+func (nn *DeepNetwork) FeedForward(inputs []float64) []float64 {
+	x := inputs[:nn.InputSize]
+	for k, layer := range nn.hidden {
+		out := nn.activations[k]
+		switch {
+		case k == 0 && nn.Gated:
+			nn.gru(x, out)
+		case k == 0:
+			layer[0].apply(x, nn.state, out, math.Tanh)
+			copy(nn.state, out)
+		default:
+			layer[0].apply(x, nil, out, math.Tanh)
+		}
+		x = out
+	}
+	nn.output.apply(x, nil, nn.outputBuffer, math.Tanh)
+	return nn.outputBuffer
+}
+
+// gru runs one step of the gated recurrent unit of the first hidden layer:
+// the update gate decides how much of the memory to replace with a
+// candidate computed from the input and the reset-gated memory.
+func (nn *DeepNetwork) gru(x, out []float64) {
+	size := nn.Sizes[0]
+	update, reset, resetState := nn.gates[:size], nn.gates[size:2*size], nn.gates[2*size:]
+	cell := nn.hidden[0]
+
+	cell[gateUpdate].apply(x, nn.state, update, sigmoid)
+	cell[gateReset].apply(x, nn.state, reset, sigmoid)
+	for i := range resetState {
+		resetState[i] = reset[i] * nn.state[i]
+	}
+	cell[gateCandidate].apply(x, resetState, out, math.Tanh)
+
+	for i := range out {
+		out[i] = (1-update[i])*nn.state[i] + update[i]*out[i]
+	}
+	copy(nn.state, out)
+}
+
+func sigmoid(x float64) float64 {
+	return 1.0 / (1.0 + math.Exp(-x))
+}
+
+func (nn *DeepNetwork) Shape() (input, hidden, output int) {
+	for _, size := range nn.Sizes {
+		hidden += size
+	}
+	return nn.InputSize, hidden, nn.OutputSize
+}
+
+func (nn *DeepNetwork) Type() string {
+	return TypeDeep
+}
+
+func (nn *DeepNetwork) Layers() int {
+	return len(nn.Sizes)
+}
+
+// Offspring builds a child with the given layer sizes from this network and,
+// if compatible, the mate. Layers present in only one parent, or only in the
+// child, are inherited or initialized as in dense.inherit.
+func (nn *DeepNetwork) Offspring(mate Brain, layers []int) Brain {
+	child := NewDeepNetwork(nn.InputSize, layers, nn.OutputSize, nn.Gated)
+
+	other, ok := mate.(*DeepNetwork)
+	if !ok || other.InputSize != nn.InputSize || other.OutputSize != nn.OutputSize || other.Gated != nn.Gated {
+		other = nil
+	}
+
+	for k, gates := range child.hidden {
+		for g, d := range gates {
+			d.inherit(nn.layer(k, g), other.layer(k, g))
+		}
+	}
+	var otherOutput *dense
+	if other != nil {
+		otherOutput = other.output
+	}
+	child.output.inherit(nn.output, otherOutput)
+	return child
+}
+
+// layer returns gate g of hidden layer k, or nil if there is none.
+func (nn *DeepNetwork) layer(k, g int) *dense {
+	if nn == nil || k >= len(nn.hidden) || g >= len(nn.hidden[k]) {
+		return nil
+	}
+	return nn.hidden[k][g]
+}
+
+func (nn *DeepNetwork) Mutate(rate, strength float64) {
+	for _, d := range nn.denseLayers() {
+		mutateSlice(d.W, rate, strength)
+		mutateSlice(d.B, rate, strength)
+	}
+}
+
+// denseLayers lists every layer in serialization order.
+func (nn *DeepNetwork) denseLayers() []*dense {
+	var all []*dense
+	for _, gates := range nn.hidden {
+		all = append(all, gates...)
+	}
+	return append(all, nn.output)
+}
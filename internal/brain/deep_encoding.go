@@ -0,0 +1,151 @@
+package brain
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+)
+
+// deepEncodingTag starts every binary DeepNetwork encoding, followed by
+// deepEncodingVersion.
+const (
+	deepEncodingTag     = 'D'
+	deepEncodingVersion = 1
+)
+
+// maxDeepLayers caps the hidden layers a binary encoding may declare.
+const maxDeepLayers = 16
+
+// deepJSON stores the architecture plus every layer's weights and biases,
+// in denseLayers order.
+type deepJSON struct {
+	Type       string
+	InputSize  int
+	OutputSize int
+	Sizes      []int
+	Gated      bool
+	Weights    [][]float64
+	Biases     [][]float64
+}
+
+func (nn *DeepNetwork) MarshalJSON() ([]byte, error) {
+	raw := deepJSON{
+		Type:       TypeDeep,
+		InputSize:  nn.InputSize,
+		OutputSize: nn.OutputSize,
+		Sizes:      nn.Sizes,
+		Gated:      nn.Gated,
+	}
+	for _, d := range nn.denseLayers() {
+		raw.Weights = append(raw.Weights, d.W)
+		raw.Biases = append(raw.Biases, d.B)
+	}
+	return json.Marshal(raw)
+}
+
+func (nn *DeepNetwork) UnmarshalJSON(data []byte) error {
+	var raw deepJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*nn = *NewDeepNetwork(raw.InputSize, raw.Sizes, raw.OutputSize, raw.Gated)
+	layers := nn.denseLayers()
+	if len(raw.Weights) != len(layers) || len(raw.Biases) != len(layers) {
+		return fmt.Errorf("brain: expected %d layers, got %d", len(layers), len(raw.Weights))
+	}
+	for i, d := range layers {
+		if len(raw.Weights[i]) != len(d.W) || len(raw.Biases[i]) != len(d.B) {
+			return fmt.Errorf("brain: layer %d does not match shape", i)
+		}
+		copy(d.W, raw.Weights[i])
+		copy(d.B, raw.Biases[i])
+	}
+	return nil
+}
+
+// MarshalBinary encodes the network as: tag and version (1 byte each),
+// input/output sizes (uint16 each), gated flag (1 byte), layer count and
+// sizes (uint16 each), then each layer's weights and biases (float64 LE).
+func (nn *DeepNetwork) MarshalBinary() ([]byte, error) {
+	buf := []byte{deepEncodingTag, deepEncodingVersion}
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(nn.InputSize))
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(nn.OutputSize))
+	if nn.Gated {
+		buf = append(buf, 1)
+	} else {
+		buf = append(buf, 0)
+	}
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(len(nn.Sizes)))
+	for _, size := range nn.Sizes {
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(size))
+	}
+	for _, d := range nn.denseLayers() {
+		buf = appendFloats(buf, d.W)
+		buf = appendFloats(buf, d.B)
+	}
+	return buf, nil
+}
+
+func (nn *DeepNetwork) UnmarshalBinary(data []byte) error {
+	if len(data) < 9 {
+		return errShortBuffer
+	}
+	if data[0] != deepEncodingTag || data[1] != deepEncodingVersion {
+		return fmt.Errorf("brain: unsupported deep network encoding %q/%d", data[0], data[1])
+	}
+	input := int(binary.LittleEndian.Uint16(data[2:]))
+	output := int(binary.LittleEndian.Uint16(data[4:]))
+	gated := data[6] == 1
+	count := int(binary.LittleEndian.Uint16(data[7:]))
+
+	if count > maxDeepLayers {
+		return fmt.Errorf("brain: encoded deep network has %d layers, at most %d allowed", count, maxDeepLayers)
+	}
+	if err := checkEncodedSizes(input, output); err != nil {
+		return err
+	}
+
+	rest := data[9:]
+	if len(rest) < 2*count {
+		return errShortBuffer
+	}
+	sizes := make([]int, count)
+	for i := range sizes {
+		sizes[i] = int(binary.LittleEndian.Uint16(rest[2*i:]))
+	}
+	rest = rest[2*count:]
+	if err := checkEncodedSizes(sizes...); err != nil {
+		return err
+	}
+
+	// The data must hold exactly the declared shape, see NewDeepNetwork
+	floats, external := 0, input
+	for k, size := range sizes {
+		switch {
+		case k == 0 && gated:
+			floats += 3 * ((external+size)*size + size)
+		case k == 0:
+			floats += (external+size)*size + size
+		default:
+			floats += external*size + size
+		}
+		external = size
+	}
+	floats += external*output + output
+	if len(rest) != 8*floats {
+		return fmt.Errorf("brain: encoded deep network has %d weight bytes, its shape needs %d", len(rest), 8*floats)
+	}
+
+	*nn = *NewDeepNetwork(input, sizes, output, gated)
+	var err error
+	for _, d := range nn.denseLayers() {
+		if rest, err = readFloats(rest, d.W); err != nil {
+			return err
+		}
+		if rest, err = readFloats(rest, d.B); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package brain
+
+import "testing"
+
+func TestDeepNetwork_OffspringAcrossShapes(t *testing.T) {
+	a := NewDeepNetwork(3, []int{4, 5}, 2, false)
+	b := NewDeepNetwork(3, []int{6, 3, 4}, 2, false)
+	for _, d := range a.denseLayers() {
+		fill(d.W, -1)
+	}
+	for _, d := range b.denseLayers() {
+		fill(d.W, 1)
+	}
+
+	child := a.Offspring(b, []int{5, 4, 3}).(*DeepNetwork)
+	if child.Layers() != 3 {
+		t.Fatalf("Expected 3 layers, got %d", child.Layers())
+	}
+	if out := child.FeedForward([]float64{0.1, 0.2, 0.3}); len(out) != 2 {
+		t.Fatalf("Expected 2 outputs, got %d", len(out))
+	}
+
+	// Input rows and columns both parents have come from either parent
+	first := child.hidden[0][0]
+	for i := 0; i < 4; i++ {
+		if w := first.W[i]; w != -1 && w != 1 {
+			t.Errorf("Shared weight %d = %f is neither parent's", i, w)
+		}
+	}
+	// Column 4 of the first layer only exists in b
+	if w := first.W[4]; w != 1 {
+		t.Errorf("Expected weight from the only parent with column 4, got %f", w)
+	}
+	// The third layer only exists in b
+	for _, w := range child.hidden[2][0].W[:3] {
+		if w != 1 {
+			t.Errorf("Expected third layer weights from b, got %f", w)
+		}
+	}
+}
+
+func TestDeepNetwork_GatedMemory(t *testing.T) {
+	nn := NewDeepNetwork(2, []int{3}, 1, true)
+	first := nn.FeedForward([]float64{1, 0})[0]
+	second := nn.FeedForward([]float64{1, 0})[0]
+	if first == second {
+		t.Errorf("GRU memory should change the output for repeated input: %f vs %f", first, second)
+	}
+
+	// Offspring start with empty memory
+	child := nn.Offspring(nil, []int{3}).(*DeepNetwork)
+	if out := child.FeedForward([]float64{1, 0})[0]; out != first {
+		t.Errorf("Fresh offspring should match the first output: %f vs %f", out, first)
+	}
+}
+
+func fill(values []float64, v float64) {
+	for i := range values {
+		values[i] = v
+	}
+}
+
+func TestDeepNetwork_UnmarshalBinaryChecksShape(t *testing.T) {
+	for _, gated := range []bool{false, true} {
+		data, _ := NewDeepNetwork(4, []int{5, 3}, 2, gated).MarshalBinary()
+		var nn DeepNetwork
+		if err := nn.UnmarshalBinary(data); err != nil {
+			t.Fatalf("round trip: %v", err)
+		}
+		if err := nn.UnmarshalBinary(data[:len(data)-8]); err == nil {
+			t.Errorf("accepted a truncated network")
+		}
+
+		// Claim thousands of huge layers behind the same header
+		bad := append([]byte(nil), data[:9]...)
+		bad[7], bad[8] = 0xff, 0xff
+		if err := nn.UnmarshalBinary(bad); err == nil {
+			t.Errorf("accepted 65535 layers")
+		}
+		bad = append(append([]byte(nil), data[:9]...), 0x20, 0x4e, 0x20, 0x4e)
+		if err := nn.UnmarshalBinary(bad); err == nil {
+			t.Errorf("accepted 20000 neuron layers")
+		}
+	}
+}
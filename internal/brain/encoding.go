@@ -0,0 +1,230 @@
+package brain
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// networkEncodingVersion is bumped whenever the binary layout changes.
+const networkEncodingVersion = 1
+
+var errShortBuffer = errors.New("brain: encoded network is truncated")
+
+// maxEncodedNeurons caps every layer size, inputs and outputs included, that
+// a binary encoding may declare. Encodings also arrive in pasted genome
+// codes, so declared shapes are checked against this and the data length
+// before anything is allocated.
+const maxEncodedNeurons = 4096
+
+// checkEncodedSizes rejects declared layer sizes above maxEncodedNeurons.
+func checkEncodedSizes(sizes ...int) error {
+	for _, size := range sizes {
+		if size > maxEncodedNeurons {
+			return fmt.Errorf("brain: encoded layer of %d neurons exceeds %d", size, maxEncodedNeurons)
+		}
+	}
+	return nil
+}
+
+// networkJSON is the serialized form of a Network.
+// Field names keep the layout of older snapshots, which only stored the sizes.
+type networkJSON struct {
+	InputSize  int
+	HiddenSize int
+	OutputSize int
+	Weights1   []float64    `json:",omitempty"`
+	Weights2   []float64    `json:",omitempty"`
+	Bias1      []float64    `json:",omitempty"`
+	Bias2      []float64    `json:",omitempty"`
+	Act1       []Activation `json:",omitempty"`
+	Act2       []Activation `json:",omitempty"`
+	Rule       *HebbianRule `json:",omitempty"`
+	Genetic1   []float64    `json:",omitempty"` // Inherited weights if the network has learned
+	Genetic2   []float64    `json:",omitempty"`
+}
+
+// MarshalJSON includes the weights so snapshots can restore behaviour.
+func (nn *Network) MarshalJSON() ([]byte, error) {
+	return json.Marshal(networkJSON{
+		InputSize:  nn.InputSize,
+		HiddenSize: nn.HiddenSize,
+		OutputSize: nn.OutputSize,
+		Weights1:   nn.weights1,
+		Weights2:   nn.weights2,
+		Bias1:      nn.bias1,
+		Bias2:      nn.bias2,
+		Act1:       nn.act1,
+		Act2:       nn.act2,
+		Rule:       &nn.rule,
+		Genetic1:   nn.genetic1,
+		Genetic2:   nn.genetic2,
+	})
+}
+
+// UnmarshalJSON restores a network. Snapshots written before weights were
+// stored get freshly randomized weights of the recorded shape; those written
+// before biases and activations existed get zero biases and tanh.
+func (nn *Network) UnmarshalJSON(data []byte) error {
+	var raw networkJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*nn = *NewNetwork(raw.InputSize, raw.HiddenSize, raw.OutputSize)
+	if raw.Weights1 == nil && raw.Weights2 == nil {
+		return nil
+	}
+	if len(raw.Weights1) != len(nn.weights1) || len(raw.Weights2) != len(nn.weights2) {
+		return fmt.Errorf("brain: weight count does not match shape %dx%dx%d", raw.InputSize, raw.HiddenSize, raw.OutputSize)
+	}
+	copy(nn.weights1, raw.Weights1)
+	copy(nn.weights2, raw.Weights2)
+
+	if raw.Rule != nil {
+		nn.rule = *raw.Rule
+	}
+	if raw.Genetic1 != nil || raw.Genetic2 != nil {
+		if len(raw.Genetic1) != len(nn.weights1) || len(raw.Genetic2) != len(nn.weights2) {
+			return fmt.Errorf("brain: inherited weight count does not match shape %dx%dx%d", raw.InputSize, raw.HiddenSize, raw.OutputSize)
+		}
+		nn.genetic1, nn.genetic2 = raw.Genetic1, raw.Genetic2
+	}
+
+	if raw.Bias1 == nil && raw.Bias2 == nil && raw.Act1 == nil && raw.Act2 == nil {
+		return nil
+	}
+	if len(raw.Bias1) != raw.HiddenSize || len(raw.Bias2) != raw.OutputSize ||
+		len(raw.Act1) != raw.HiddenSize || len(raw.Act2) != raw.OutputSize {
+		return fmt.Errorf("brain: neuron parameter count does not match shape %dx%dx%d", raw.InputSize, raw.HiddenSize, raw.OutputSize)
+	}
+	copy(nn.bias1, raw.Bias1)
+	copy(nn.bias2, raw.Bias2)
+	copy(nn.act1, raw.Act1)
+	copy(nn.act2, raw.Act2)
+	return nil
+}
+
+// MarshalBinary encodes the network as:
+// version (1 byte), input/hidden/output sizes (uint16 each), weights1, weights2,
+// bias1, bias2 (float64 LE), the hidden and output activations (1 byte each),
+// the Hebbian rule (5 float64) and a flag byte, followed by the inherited
+// weights1 and weights2 if the network has learned.
+func (nn *Network) MarshalBinary() ([]byte, error) {
+	floats := len(nn.weights1) + len(nn.weights2) + len(nn.bias1) + len(nn.bias2)
+	buf := make([]byte, 0, 7+8*floats+len(nn.act1)+len(nn.act2))
+	buf = append(buf, networkEncodingVersion)
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(nn.InputSize))
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(nn.HiddenSize))
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(nn.OutputSize))
+	buf = appendFloats(buf, nn.weights1)
+	buf = appendFloats(buf, nn.weights2)
+	buf = appendFloats(buf, nn.bias1)
+	buf = appendFloats(buf, nn.bias2)
+	for _, a := range nn.act1 {
+		buf = append(buf, byte(a))
+	}
+	for _, a := range nn.act2 {
+		buf = append(buf, byte(a))
+	}
+	buf = appendFloats(buf, []float64{nn.rule.Rate, nn.rule.A, nn.rule.B, nn.rule.C, nn.rule.D})
+	if nn.genetic1 == nil {
+		return append(buf, 0), nil
+	}
+	buf = append(buf, 1)
+	buf = appendFloats(buf, nn.genetic1)
+	buf = appendFloats(buf, nn.genetic2)
+	return buf, nil
+}
+
+// UnmarshalBinary decodes a network produced by MarshalBinary.
+func (nn *Network) UnmarshalBinary(data []byte) error {
+	if len(data) < 7 {
+		return errShortBuffer
+	}
+	version := data[0]
+	if version != networkEncodingVersion {
+		return fmt.Errorf("brain: unsupported network encoding version %d", version)
+	}
+	input := int(binary.LittleEndian.Uint16(data[1:]))
+	hidden := int(binary.LittleEndian.Uint16(data[3:]))
+	output := int(binary.LittleEndian.Uint16(data[5:]))
+	if err := checkEncodedSizes(input, hidden, output); err != nil {
+		return err
+	}
+
+	// The data must hold exactly the declared shape
+	weights := (input+hidden)*hidden + hidden*output
+	size := 7 + 8*weights + 8*(hidden+output) + hidden + output + 8*5 + 1
+	if len(data) == size+8*weights {
+		size += 8 * weights // Genetic weights of a Lamarckian learner
+	}
+	if len(data) != size {
+		return fmt.Errorf("brain: encoded network of %d bytes, its shape needs %d", len(data), size)
+	}
+
+	*nn = *NewNetwork(input, hidden, output)
+	rest := data[7:]
+	var err error
+	if rest, err = readFloats(rest, nn.weights1); err != nil {
+		return err
+	}
+	if rest, err = readFloats(rest, nn.weights2); err != nil {
+		return err
+	}
+	if rest, err = readFloats(rest, nn.bias1); err != nil {
+		return err
+	}
+	if rest, err = readFloats(rest, nn.bias2); err != nil {
+		return err
+	}
+	if len(rest) < hidden+output {
+		return errShortBuffer
+	}
+	for i := range nn.act1 {
+		nn.act1[i] = Activation(rest[i])
+	}
+	for i := range nn.act2 {
+		nn.act2[i] = Activation(rest[hidden+i])
+	}
+	rest = rest[hidden+output:]
+
+	rule := make([]float64, 5)
+	if rest, err = readFloats(rest, rule); err != nil {
+		return err
+	}
+	nn.rule = HebbianRule{Rate: rule[0], A: rule[1], B: rule[2], C: rule[3], D: rule[4]}
+	if len(rest) < 1 {
+		return errShortBuffer
+	}
+	if rest[0] == 1 {
+		nn.genetic1 = make([]float64, len(nn.weights1))
+		nn.genetic2 = make([]float64, len(nn.weights2))
+		if rest, err = readFloats(rest[1:], nn.genetic1); err != nil {
+			return err
+		}
+		if _, err = readFloats(rest, nn.genetic2); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendFloats(buf []byte, values []float64) []byte {
+	for _, v := range values {
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(v))
+	}
+	return buf
+}
+
+func readFloats(data []byte, dst []float64) ([]byte, error) {
+	if len(data) < 8*len(dst) {
+		return nil, errShortBuffer
+	}
+	for i := range dst {
+		dst[i] = math.Float64frombits(binary.LittleEndian.Uint64(data[i*8:]))
+	}
+	return data[8*len(dst):], nil
+}
@@ -0,0 +1,248 @@
+package brain
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// Graph is a brain's structure and activity on its last tick, for
+// inspection. Inputs are nodes 0..InputSize-1 and outputs follow them; hidden
+// node IDs are above both. Input values are left zero, since the caller
+// knows what it fed the brain.
+type Graph struct {
+	Type  string      `json:"type"`
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// Node kinds.
+const (
+	NodeInput  = "input"
+	NodeHidden = "hidden"
+	NodeOutput = "output"
+)
+
+type GraphNode struct {
+	ID         int     `json:"id"`
+	Kind       string  `json:"kind"`
+	Layer      int     `json:"layer"` // 0 for inputs, outputs are last
+	Activation string  `json:"activation,omitempty"`
+	Bias       float64 `json:"bias"`
+	Value      float64 `json:"value"`
+}
+
+type GraphEdge struct {
+	From      int     `json:"from"`
+	To        int     `json:"to"`
+	Weight    float64 `json:"weight"`
+	Recurrent bool    `json:"recurrent,omitempty"` // Reads the previous tick's value
+	Gate      string  `json:"gate,omitempty"`      // GRU gate the weight belongs to
+	Disabled  bool    `json:"disabled,omitempty"`
+}
+
+// graphBuilder numbers nodes the way Graph describes.
+type graphBuilder struct {
+	Graph
+	input, output int
+	next          int // Next hidden node ID
+}
+
+func newGraphBuilder(kind string, input, output int) *graphBuilder {
+	b := &graphBuilder{Graph: Graph{Type: kind}, input: input, output: output, next: input + output}
+	for i := 0; i < input; i++ {
+		b.Nodes = append(b.Nodes, GraphNode{ID: i, Kind: NodeInput})
+	}
+	return b
+}
+
+// hidden adds a layer of hidden nodes and returns their IDs.
+func (b *graphBuilder) hidden(layer int, values, bias []float64, act func(i int) Activation) []int {
+	ids := make([]int, len(values))
+	for i, v := range values {
+		ids[i] = b.next
+		b.next++
+		b.Nodes = append(b.Nodes, GraphNode{ID: ids[i], Kind: NodeHidden, Layer: layer, Activation: act(i).String(), Bias: bias[i], Value: v})
+	}
+	return ids
+}
+
+func (b *graphBuilder) outputs(layer int, values, bias []float64, act func(i int) Activation) []int {
+	ids := make([]int, len(values))
+	for i, v := range values {
+		ids[i] = b.input + i
+		b.Nodes = append(b.Nodes, GraphNode{ID: ids[i], Kind: NodeOutput, Layer: layer, Activation: act(i).String(), Bias: bias[i], Value: v})
+	}
+	return ids
+}
+
+func (b *graphBuilder) inputIDs() []int {
+	ids := make([]int, b.input)
+	for i := range ids {
+		ids[i] = i
+	}
+	return ids
+}
+
+// connect adds the edges of a weight matrix laid out row*len(to) + column,
+// with one row per from node.
+func (b *graphBuilder) connect(from, to []int, weights []float64, recurrent bool, gate string) {
+	for j, f := range from {
+		for i, t := range to {
+			b.Edges = append(b.Edges, GraphEdge{From: f, To: t, Weight: weights[j*len(to)+i], Recurrent: recurrent, Gate: gate})
+		}
+	}
+}
+
+func tanhAt(int) Activation { return Tanh }
+
+func (nn *Network) Graph() Graph {
+	b := newGraphBuilder(TypeElman, nn.InputSize, nn.OutputSize)
+	hidden := b.hidden(1, nn.hiddenState, nn.bias1, func(i int) Activation { return nn.act1[i] })
+	outputs := b.outputs(2, nn.outputBuffer, nn.bias2, func(i int) Activation { return nn.act2[i] })
+
+	split := nn.InputSize * nn.HiddenSize
+	b.connect(b.inputIDs(), hidden, nn.weights1[:split], false, "")
+	b.connect(hidden, hidden, nn.weights1[split:], true, "")
+	b.connect(hidden, outputs, nn.weights2, false, "")
+	return b.Graph
+}
+
+func (nn *DeepNetwork) Graph() Graph {
+	b := newGraphBuilder(TypeDeep, nn.InputSize, nn.OutputSize)
+	prev := b.inputIDs()
+	for k, layer := range nn.hidden {
+		d := layer[0]
+		if k == 0 && nn.Gated {
+			d = layer[gateCandidate]
+		}
+		ids := b.hidden(k+1, nn.activations[k], d.B, tanhAt)
+
+		if k == 0 && nn.Gated {
+			for g, name := range []string{"update", "reset", "candidate"} {
+				w := layer[g].W
+				split := layer[g].External * layer[g].Out
+				b.connect(prev, ids, w[:split], false, name)
+				b.connect(ids, ids, w[split:], true, name)
+			}
+		} else {
+			split := d.External * d.Out
+			b.connect(prev, ids, d.W[:split], false, "")
+			if d.Context > 0 {
+				b.connect(ids, ids, d.W[split:], true, "")
+			}
+		}
+		prev = ids
+	}
+	outputs := b.outputs(len(nn.hidden)+1, nn.outputBuffer, nn.output.B, tanhAt)
+	b.connect(prev, outputs, nn.output.W, false, "")
+	return b.Graph
+}
+
+func (n *NEAT) Graph() Graph {
+	if !n.compiled {
+		n.compile()
+	}
+	g := Graph{Type: TypeNEAT}
+	size := n.InputSize + n.OutputSize
+
+	// Evaluation order decides which links read last tick's values
+	order := make(map[int]int, size+len(n.Hidden))
+	for i := 0; i < n.InputSize; i++ {
+		g.Nodes = append(g.Nodes, GraphNode{ID: i, Kind: NodeInput})
+		order[i] = -1
+	}
+	for i, id := range n.Hidden {
+		g.Nodes = append(g.Nodes, GraphNode{ID: id, Kind: NodeHidden, Layer: 1, Activation: Tanh.String(), Value: n.values[size+i]})
+		order[id] = i
+	}
+	for o := 0; o < n.OutputSize; o++ {
+		id := n.InputSize + o
+		g.Nodes = append(g.Nodes, GraphNode{ID: id, Kind: NodeOutput, Layer: 2, Activation: Tanh.String(), Value: n.values[id]})
+		order[id] = len(n.Hidden) + o
+	}
+
+	for _, c := range n.Connections {
+		g.Edges = append(g.Edges, GraphEdge{
+			From:      c.From,
+			To:        c.To,
+			Weight:    c.Weight,
+			Recurrent: order[c.From] >= order[c.To],
+			Disabled:  !c.Enabled,
+		})
+	}
+	return g
+}
+
+// WriteDOT writes the graph in GraphViz DOT format, one rank per layer.
+// Inputs and outputs are labelled with the given names where there are any.
+// Edge colour shows the sign of the weight, width its magnitude.
+func WriteDOT(w io.Writer, g Graph, inputNames, outputNames []string) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "digraph brain {\n\tlabel=%q;\n\trankdir=LR;\n\tnode [fontsize=10];\n", g.Type)
+
+	layers := map[int][]GraphNode{}
+	maxLayer, inputs := 0, 0
+	for _, n := range g.Nodes {
+		layers[n.Layer] = append(layers[n.Layer], n)
+		maxLayer = max(maxLayer, n.Layer)
+		if n.Kind == NodeInput {
+			inputs++
+		}
+	}
+	for layer := 0; layer <= maxLayer; layer++ {
+		if len(layers[layer]) == 0 {
+			continue
+		}
+		sb.WriteString("\t{ rank=same;")
+		for _, n := range layers[layer] {
+			fmt.Fprintf(&sb, " n%d;", n.ID)
+		}
+		sb.WriteString(" }\n")
+	}
+
+	for _, n := range g.Nodes {
+		label := fmt.Sprintf("h%d", n.ID)
+		shape := "circle"
+		switch n.Kind {
+		case NodeInput:
+			label, shape = nodeName(inputNames, n.ID, "in"), "box"
+		case NodeOutput:
+			label, shape = nodeName(outputNames, n.ID-inputs, "out"), "doublecircle"
+		}
+		if n.Activation != "" && n.Kind != NodeInput {
+			label += "\\n" + n.Activation
+		}
+		fmt.Fprintf(&sb, "\tn%d [label=\"%s\\n%.2f\", shape=%s];\n", n.ID, label, n.Value, shape)
+	}
+
+	for _, e := range g.Edges {
+		color := "firebrick"
+		if e.Weight >= 0 {
+			color = "forestgreen"
+		}
+		attrs := fmt.Sprintf("color=%s, penwidth=%.2f, label=\"%.2f\"", color, 0.5+min(math.Abs(e.Weight), 5)*0.5, e.Weight)
+		if e.Recurrent {
+			attrs += ", style=dashed, constraint=false"
+		}
+		if e.Disabled {
+			attrs += ", style=dotted, color=gray"
+		}
+		if e.Gate != "" {
+			attrs += ", xlabel=\"" + e.Gate + "\""
+		}
+		fmt.Fprintf(&sb, "\tn%d -> n%d [%s];\n", e.From, e.To, attrs)
+	}
+	sb.WriteString("}\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func nodeName(names []string, i int, prefix string) string {
+	if i >= 0 && i < len(names) {
+		return names[i]
+	}
+	return fmt.Sprintf("%s%d", prefix, i)
+}
@@ -0,0 +1,62 @@
+package brain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGraph_HasAnEdgePerWeight(t *testing.T) {
+	neat := NewNEAT(3, 4, 2)
+	neat.Mutate(1, 0.5)
+	brains := map[string]struct {
+		brain Brain
+		edges int
+	}{
+		"elman": {NewNetwork(3, 4, 2), (3+4)*4 + 4*2},
+		"deep":  {NewDeepNetwork(3, []int{4, 5}, 2, false), (3+4)*4 + 4*5 + 5*2},
+		"gru":   {NewDeepNetwork(3, []int{4}, 2, true), 3*(3+4)*4 + 4*2},
+		"neat":  {neat, len(neat.Connections)},
+	}
+
+	for name, tc := range brains {
+		tc.brain.FeedForward([]float64{1, -1, 0.5})
+		g := tc.brain.Graph()
+		if len(g.Edges) != tc.edges {
+			t.Errorf("%s: %d edges, want %d", name, len(g.Edges), tc.edges)
+		}
+
+		in, hidden, out := tc.brain.Shape()
+		nodes := map[int]bool{}
+		kinds := map[string]int{}
+		for _, n := range g.Nodes {
+			nodes[n.ID] = true
+			kinds[n.Kind]++
+		}
+		if kinds[NodeInput] != in || kinds[NodeHidden] != hidden || kinds[NodeOutput] != out {
+			t.Errorf("%s: node kinds %v, want %d/%d/%d", name, kinds, in, hidden, out)
+		}
+		for _, e := range g.Edges {
+			if !nodes[e.From] || !nodes[e.To] {
+				t.Fatalf("%s: edge %d->%d references a missing node", name, e.From, e.To)
+			}
+		}
+
+		var dot strings.Builder
+		if err := WriteDOT(&dot, g, []string{"a", "b", "c"}, []string{"x", "y"}); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(dot.String(), "digraph") || !strings.Contains(dot.String(), `label="y`) {
+			t.Errorf("%s: unexpected DOT output:\n%s", name, dot.String())
+		}
+	}
+}
+
+func TestGraph_OutputValuesMatchFeedForward(t *testing.T) {
+	nn := NewNetwork(2, 3, 2)
+	out := nn.FeedForward([]float64{0.3, -0.7})
+	for _, n := range nn.Graph().Nodes {
+		if n.Kind == NodeOutput && n.Value != out[n.ID-2] {
+			t.Errorf("output node %d has value %f, FeedForward gave %f", n.ID, n.Value, out[n.ID-2])
+		}
+	}
+}
@@ -0,0 +1,423 @@
+package brain
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sync"
+)
+
+// Structural mutation chances, relative to the mutation rate.
+const (
+	addConnectionRate = 0.5
+	addNodeRate       = 0.3
+	toggleRate        = 0.1
+)
+
+// firstHiddenNode keeps hidden node IDs clear of input and output IDs, which
+// are 0..InputSize-1 and InputSize..InputSize+OutputSize-1.
+const firstHiddenNode = 1 << 20
+
+// ConnectionGene is a weighted link between two nodes. Genes with the same
+// innovation number describe the same structural mutation in every genome,
+// which is what lets crossover line parents up.
+type ConnectionGene struct {
+	Innovation int
+	From, To   int
+	Weight     float64
+	Enabled    bool
+}
+
+// NEAT is a network whose topology evolves: mutations add hidden nodes by
+// splitting connections, add connections and enable or disable them.
+//
+// Nodes are evaluated once per tick in order (inputs, hidden nodes in the
+// order they were added, outputs). A link from a node that comes later in
+// that order reads the node's previous value, so cycles act as memory.
+type NEAT struct {
+	InputSize  int
+	OutputSize int
+	// MaxHidden caps how many hidden nodes mutations may add. It comes from
+	// the genome's hidden alleles; crossover can still exceed it.
+	MaxHidden   int
+	Hidden      []int            // Hidden node IDs in evaluation order
+	Connections []ConnectionGene // Sorted by innovation
+
+	// Compiled form, rebuilt after structural changes
+	compiled     bool
+	values       []float64 // Node outputs by slot; inputs, outputs, then hidden
+	incoming     [][]link  // Per slot, for hidden and output slots
+	outputBuffer []float64
+}
+
+type link struct {
+	from   int // Slot
+	weight float64
+}
+
+// NewNEAT creates a minimal network: every input connected to every output,
+// with no hidden nodes yet.
+func NewNEAT(input, maxHidden, output int) *NEAT {
+	n := &NEAT{InputSize: input, OutputSize: output, MaxHidden: maxHidden}
+	for i := 0; i < input; i++ {
+		for o := 0; o < output; o++ {
+			to := input + o
+			n.Connections = append(n.Connections, ConnectionGene{
+				Innovation: innovations.connection(i, to),
+				From:       i,
+				To:         to,
+				Weight:     rand.Float64()*2.0 - 1.0,
+				Enabled:    true,
+			})
+		}
+	}
+	n.sortConnections()
+	return n
+}
+
+func (n *NEAT) Shape() (input, hidden, output int) {
+	return n.InputSize, len(n.Hidden), n.OutputSize
+}
+
+func (n *NEAT) Type() string {
+	return TypeNEAT
+}
+
+func (n *NEAT) FeedForward(inputs []float64) []float64 {
+	if !n.compiled {
+		n.compile()
+	}
+
+	copy(n.values, inputs[:n.InputSize])
+
+	// Hidden slots follow the outputs, but are evaluated first
+	firstHidden := n.InputSize + n.OutputSize
+	for slot := firstHidden; slot < len(n.values); slot++ {
+		n.values[slot] = n.activate(slot)
+	}
+	for slot := n.InputSize; slot < firstHidden; slot++ {
+		n.values[slot] = n.activate(slot)
+	}
+
+	copy(n.outputBuffer, n.values[n.InputSize:firstHidden])
+	return n.outputBuffer
+}
+
+func (n *NEAT) activate(slot int) float64 {
+	sum := 0.0
+	for _, l := range n.incoming[slot] {
+		sum += n.values[l.from] * l.weight
+	}
+	return math.Tanh(sum)
+}
+
+// compile maps node IDs to slots and groups enabled links by target.
+func (n *NEAT) compile() {
+	slots := make(map[int]int, len(n.Hidden))
+	size := n.InputSize + n.OutputSize
+	for i, id := range n.Hidden {
+		slots[id] = size + i
+	}
+	slotOf := func(id int) int {
+		if id < size {
+			return id
+		}
+		return slots[id]
+	}
+
+	n.values = make([]float64, size+len(n.Hidden)) // Zeroed — no memory yet
+	n.incoming = make([][]link, len(n.values))
+	for _, c := range n.Connections {
+		if c.Enabled {
+			to := slotOf(c.To)
+			n.incoming[to] = append(n.incoming[to], link{from: slotOf(c.From), weight: c.Weight})
+		}
+	}
+	n.outputBuffer = make([]float64, n.OutputSize)
+	n.compiled = true
+}
+
+func (n *NEAT) Clone() *NEAT {
+	return &NEAT{
+		InputSize:   n.InputSize,
+		OutputSize:  n.OutputSize,
+		MaxHidden:   n.MaxHidden,
+		Hidden:      slices.Clone(n.Hidden),
+		Connections: slices.Clone(n.Connections),
+	}
+}
+
+// Offspring copies the network, or crosses it over with a NEAT mate of the
+// same input/output shape, and sets the child's hidden node budget.
+func (n *NEAT) Offspring(mate Brain, layers []int) Brain {
+	var child *NEAT
+	if other, ok := mate.(*NEAT); ok && other.InputSize == n.InputSize && other.OutputSize == n.OutputSize {
+		child = n.Crossover(other)
+	} else {
+		child = n.Clone()
+	}
+	child.MaxHidden = layers[0]
+	return child
+}
+
+func (n *NEAT) Layers() int {
+	return 1
+}
+
+// Crossover lines up connection genes by innovation number. Matching genes
+// take either parent's weight; genes only the mate has are inherited with
+// even odds, since neither parent is known to be fitter. A gene disabled in
+// either parent is usually disabled in the child.
+func (n *NEAT) Crossover(other *NEAT) *NEAT {
+	child := n.Clone()
+
+	byInnovation := make(map[int]ConnectionGene, len(other.Connections))
+	for _, c := range other.Connections {
+		byInnovation[c.Innovation] = c
+	}
+
+	for i, c := range child.Connections {
+		m, ok := byInnovation[c.Innovation]
+		if !ok {
+			continue
+		}
+		delete(byInnovation, c.Innovation)
+		if rand.Float64() < 0.5 {
+			child.Connections[i].Weight = m.Weight
+		}
+		if !c.Enabled || !m.Enabled {
+			child.Connections[i].Enabled = rand.Float64() >= 0.75
+		}
+	}
+
+	hasNode := make(map[int]bool, len(child.Hidden))
+	for _, id := range child.Hidden {
+		hasNode[id] = true
+	}
+	var needed []ConnectionGene
+	for _, c := range other.Connections {
+		if _, ok := byInnovation[c.Innovation]; ok && !child.linked(c.From, c.To) && rand.Float64() < 0.5 {
+			needed = append(needed, c)
+		}
+	}
+	for _, c := range needed {
+		child.Connections = append(child.Connections, c)
+		for _, id := range []int{c.From, c.To} {
+			if id >= firstHiddenNode && !hasNode[id] {
+				hasNode[id] = true
+				child.Hidden = append(child.Hidden, id)
+			}
+		}
+	}
+
+	child.sortConnections()
+	return child
+}
+
+func (n *NEAT) Mutate(rate, strength float64) {
+	for i := range n.Connections {
+		if rand.Float64() < rate {
+			w := n.Connections[i].Weight + rand.NormFloat64()*strength
+			n.Connections[i].Weight = math.Max(-5.0, math.Min(5.0, w))
+		}
+	}
+
+	if rand.Float64() < rate*addConnectionRate {
+		n.addConnection()
+	}
+	if rand.Float64() < rate*addNodeRate {
+		n.addNode()
+	}
+	if rand.Float64() < rate*toggleRate && len(n.Connections) > 0 {
+		i := rand.IntN(len(n.Connections))
+		n.Connections[i].Enabled = !n.Connections[i].Enabled
+	}
+
+	n.compiled = false
+}
+
+// addConnection links a random source (input or hidden) to a random target
+// (hidden or output) that aren't linked yet.
+func (n *NEAT) addConnection() {
+	sources := n.InputSize + len(n.Hidden)
+	targets := len(n.Hidden) + n.OutputSize
+
+	for attempt := 0; attempt < 10; attempt++ {
+		from := rand.IntN(sources)
+		if from >= n.InputSize {
+			from = n.Hidden[from-n.InputSize]
+		}
+		to := rand.IntN(targets)
+		if to < len(n.Hidden) {
+			to = n.Hidden[to]
+		} else {
+			to = n.InputSize + to - len(n.Hidden)
+		}
+		if from == to || n.linked(from, to) {
+			continue
+		}
+
+		n.Connections = append(n.Connections, ConnectionGene{
+			Innovation: innovations.connection(from, to),
+			From:       from,
+			To:         to,
+			Weight:     rand.Float64()*2.0 - 1.0,
+			Enabled:    true,
+		})
+		n.sortConnections()
+		return
+	}
+}
+
+// addNode splits an enabled connection A->B into A->new->B. The incoming link
+// gets weight 1 and the outgoing one the old weight, so behaviour barely
+// changes at first.
+func (n *NEAT) addNode() {
+	if len(n.Hidden) >= n.MaxHidden {
+		return
+	}
+
+	var enabled []int
+	for i, c := range n.Connections {
+		if c.Enabled {
+			enabled = append(enabled, i)
+		}
+	}
+	if len(enabled) == 0 {
+		return
+	}
+	split := n.Connections[enabled[rand.IntN(len(enabled))]]
+
+	node := innovations.split(split.Innovation)
+	if slices.Contains(n.Hidden, node) {
+		return // Split before and re-enabled since
+	}
+
+	for i := range n.Connections {
+		if n.Connections[i].Innovation == split.Innovation {
+			n.Connections[i].Enabled = false
+		}
+	}
+	n.Hidden = append(n.Hidden, node)
+	n.Connections = append(n.Connections,
+		ConnectionGene{Innovation: innovations.connection(split.From, node), From: split.From, To: node, Weight: 1, Enabled: true},
+		ConnectionGene{Innovation: innovations.connection(node, split.To), From: node, To: split.To, Weight: split.Weight, Enabled: true},
+	)
+	n.sortConnections()
+}
+
+func (n *NEAT) linked(from, to int) bool {
+	for _, c := range n.Connections {
+		if c.From == from && c.To == to {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *NEAT) sortConnections() {
+	slices.SortFunc(n.Connections, func(a, b ConnectionGene) int { return a.Innovation - b.Innovation })
+}
+
+// innovationTracker hands out innovation numbers and node IDs so the same
+// structural mutation gets the same numbers wherever it happens.
+type innovationTracker struct {
+	mu             sync.Mutex
+	connections    map[[2]int]int // (from, to) -> innovation
+	splits         map[int]int    // split connection innovation -> node ID
+	nextInnovation int
+	nextNode       int
+}
+
+var innovations = &innovationTracker{
+	connections:    make(map[[2]int]int),
+	splits:         make(map[int]int),
+	nextInnovation: 1,
+	nextNode:       firstHiddenNode,
+}
+
+func (t *innovationTracker) connection(from, to int) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := [2]int{from, to}
+	if id, ok := t.connections[key]; ok {
+		return id
+	}
+	id := t.nextInnovation
+	t.nextInnovation++
+	t.connections[key] = id
+	return id
+}
+
+func (t *innovationTracker) split(innovation int) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if id, ok := t.splits[innovation]; ok {
+		return id
+	}
+	id := t.nextNode
+	t.nextNode++
+	t.splits[innovation] = id
+	return id
+}
+
+// Adopt registers the genes of a decoded brain with the innovation tracker,
+// so numbers handed out later don't collide with its own and splitting the
+// same connection again reuses its node. Call it once the brain joins a
+// world; brains other than NEAT need nothing.
+func Adopt(b Brain) {
+	if n, ok := b.(*NEAT); ok {
+		innovations.observe(n)
+	}
+}
+
+// observe registers genes of a decoded network, so numbers handed out
+// after restoring a snapshot don't collide with existing ones.
+func (t *innovationTracker) observe(n *NEAT) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, c := range n.Connections {
+		key := [2]int{c.From, c.To}
+		if _, ok := t.connections[key]; !ok {
+			t.connections[key] = c.Innovation
+		}
+		if c.Innovation >= t.nextInnovation {
+			t.nextInnovation = c.Innovation + 1
+		}
+	}
+	for _, id := range n.Hidden {
+		if id >= t.nextNode {
+			t.nextNode = id + 1
+		}
+		// A split links from -> node -> to before any other link of the
+		// node, so its oldest in and out links name the split connection
+		from, to := n.splitEnds(id)
+		if from < 0 || to < 0 {
+			continue
+		}
+		if innovation, ok := t.connections[[2]int{from, to}]; ok {
+			if _, ok := t.splits[innovation]; !ok {
+				t.splits[innovation] = id
+			}
+		}
+	}
+}
+
+// splitEnds returns the nodes on either side of the connection that hidden
+// node id split, or -1 where the genome lacks the link.
+func (n *NEAT) splitEnds(id int) (from, to int) {
+	from, to = -1, -1
+	in, out := 0, 0
+	for _, c := range n.Connections {
+		if c.To == id && (from < 0 || c.Innovation < in) {
+			from, in = c.From, c.Innovation
+		}
+		if c.From == id && (to < 0 || c.Innovation < out) {
+			to, out = c.To, c.Innovation
+		}
+	}
+	return from, to
+}
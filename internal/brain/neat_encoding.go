@@ -0,0 +1,143 @@
+package brain
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// neatEncodingTag starts every binary NEAT encoding, followed by
+// neatEncodingVersion. It can't be mistaken for a Network version byte.
+const (
+	neatEncodingTag     = 'N'
+	neatEncodingVersion = 1
+)
+
+type neatJSON struct {
+	Type        string
+	InputSize   int
+	OutputSize  int
+	MaxHidden   int
+	Hidden      []int
+	Connections []ConnectionGene
+}
+
+func (n *NEAT) MarshalJSON() ([]byte, error) {
+	return json.Marshal(neatJSON{
+		Type:        TypeNEAT,
+		InputSize:   n.InputSize,
+		OutputSize:  n.OutputSize,
+		MaxHidden:   n.MaxHidden,
+		Hidden:      n.Hidden,
+		Connections: n.Connections,
+	})
+}
+
+func (n *NEAT) UnmarshalJSON(data []byte) error {
+	var raw neatJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*n = NEAT{
+		InputSize:   raw.InputSize,
+		OutputSize:  raw.OutputSize,
+		MaxHidden:   raw.MaxHidden,
+		Hidden:      raw.Hidden,
+		Connections: raw.Connections,
+	}
+	return n.validate()
+}
+
+// MarshalBinary encodes the network as: tag and version (1 byte each),
+// input/output sizes and hidden budget (uint16 each), hidden node IDs
+// (uint32 count, int32 each), connections (uint32 count, then innovation,
+// from and to as int32, weight as float64 LE and an enabled byte each).
+func (n *NEAT) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 0, 16+4*len(n.Hidden)+21*len(n.Connections))
+	buf = append(buf, neatEncodingTag, neatEncodingVersion)
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(n.InputSize))
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(n.OutputSize))
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(n.MaxHidden))
+
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(n.Hidden)))
+	for _, id := range n.Hidden {
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(id))
+	}
+
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(n.Connections)))
+	for _, c := range n.Connections {
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(c.Innovation))
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(c.From))
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(c.To))
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(c.Weight))
+		if c.Enabled {
+			buf = append(buf, 1)
+		} else {
+			buf = append(buf, 0)
+		}
+	}
+	return buf, nil
+}
+
+func (n *NEAT) UnmarshalBinary(data []byte) error {
+	if len(data) < 12 {
+		return errShortBuffer
+	}
+	if data[0] != neatEncodingTag || data[1] != neatEncodingVersion {
+		return fmt.Errorf("brain: unsupported NEAT encoding %q/%d", data[0], data[1])
+	}
+	*n = NEAT{
+		InputSize:  int(binary.LittleEndian.Uint16(data[2:])),
+		OutputSize: int(binary.LittleEndian.Uint16(data[4:])),
+		MaxHidden:  int(binary.LittleEndian.Uint16(data[6:])),
+	}
+
+	rest := data[8:]
+	count := int(binary.LittleEndian.Uint32(rest))
+	rest = rest[4:]
+	if len(rest) < 4*count+4 {
+		return errShortBuffer
+	}
+	n.Hidden = make([]int, count)
+	for i := range n.Hidden {
+		n.Hidden[i] = int(int32(binary.LittleEndian.Uint32(rest[4*i:])))
+	}
+	rest = rest[4*count:]
+
+	count = int(binary.LittleEndian.Uint32(rest))
+	rest = rest[4:]
+	if len(rest) < 21*count {
+		return errShortBuffer
+	}
+	n.Connections = make([]ConnectionGene, count)
+	for i := range n.Connections {
+		rec := rest[21*i:]
+		n.Connections[i] = ConnectionGene{
+			Innovation: int(int32(binary.LittleEndian.Uint32(rec[0:]))),
+			From:       int(int32(binary.LittleEndian.Uint32(rec[4:]))),
+			To:         int(int32(binary.LittleEndian.Uint32(rec[8:]))),
+			Weight:     math.Float64frombits(binary.LittleEndian.Uint64(rec[12:])),
+			Enabled:    rec[20] == 1,
+		}
+	}
+	return n.validate()
+}
+
+// validate checks that connections only reference known nodes. It leaves
+// the innovation tracker alone; see Adopt.
+func (n *NEAT) validate() error {
+	known := make(map[int]bool, len(n.Hidden))
+	for _, id := range n.Hidden {
+		known[id] = true
+	}
+	for _, c := range n.Connections {
+		for _, id := range []int{c.From, c.To} {
+			if id < 0 || (id >= n.InputSize+n.OutputSize && !known[id]) {
+				return fmt.Errorf("brain: connection %d references unknown node %d", c.Innovation, id)
+			}
+		}
+	}
+	n.sortConnections()
+	return nil
+}
@@ -0,0 +1,124 @@
+package brain
+
+import "testing"
+
+func TestNEAT_MutationGrowsWithinBudget(t *testing.T) {
+	n := NewNEAT(3, 4, 2)
+	if len(n.Connections) != 6 || len(n.Hidden) != 0 {
+		t.Fatalf("Expected 6 connections and no hidden nodes, got %d/%d", len(n.Connections), len(n.Hidden))
+	}
+
+	for i := 0; i < 500; i++ {
+		n.Mutate(1.0, 0.1)
+	}
+
+	if len(n.Hidden) == 0 || len(n.Hidden) > 4 {
+		t.Errorf("Expected 1-4 hidden nodes, got %d", len(n.Hidden))
+	}
+	for i := 1; i < len(n.Connections); i++ {
+		if n.Connections[i-1].Innovation >= n.Connections[i].Innovation {
+			t.Fatalf("Connections not sorted by unique innovation: %v", n.Connections)
+		}
+	}
+	if out := n.FeedForward([]float64{1, -1, 0.5}); len(out) != 2 {
+		t.Errorf("Expected 2 outputs, got %d", len(out))
+	}
+}
+
+func TestNEAT_CrossoverAlignsInnovations(t *testing.T) {
+	a := NewNEAT(2, 3, 1)
+	b := NewNEAT(2, 3, 1)
+	for i := range a.Connections {
+		if a.Connections[i].Innovation != b.Connections[i].Innovation {
+			t.Fatal("Same initial structure should share innovation numbers")
+		}
+		a.Connections[i].Weight = -1
+		b.Connections[i].Weight = 1
+	}
+	b.addNode()
+
+	child := a.Offspring(b, []int{5}).(*NEAT)
+	if child.MaxHidden != 5 {
+		t.Errorf("Expected hidden budget 5, got %d", child.MaxHidden)
+	}
+	for _, c := range child.Connections {
+		if c.Weight != -1 && c.Weight != 1 {
+			t.Errorf("Gene %d weight %f is neither parent's", c.Innovation, c.Weight)
+		}
+	}
+	// Nodes referenced by inherited genes must exist
+	child.FeedForward([]float64{1, 1})
+	if err := child.validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDecode_TellsBrainTypesApart(t *testing.T) {
+	n := NewNEAT(3, 4, 2)
+	for i := 0; i < 50; i++ {
+		n.Mutate(1.0, 0.1)
+	}
+	in := []float64{0.3, -0.2, 0.9}
+
+	for _, orig := range []Brain{n, NewNetwork(3, 4, 2), NewDeepNetwork(3, []int{4, 3}, 2, true)} {
+		bin, _ := orig.MarshalBinary()
+		fromBinary, err := DecodeBinary(bin)
+		if err != nil {
+			t.Fatalf("%s: DecodeBinary failed: %v", orig.Type(), err)
+		}
+		js, _ := orig.MarshalJSON()
+		fromJSON, err := DecodeJSON(js)
+		if err != nil {
+			t.Fatalf("%s: DecodeJSON failed: %v", orig.Type(), err)
+		}
+
+		want := orig.Offspring(nil, []int{4, 3}[:orig.Layers()]).FeedForward(in)[0]
+		for _, got := range []Brain{fromBinary, fromJSON} {
+			if got.Type() != orig.Type() {
+				t.Errorf("Expected type %s, got %s", orig.Type(), got.Type())
+			}
+			if out := got.FeedForward(in)[0]; out != want {
+				t.Errorf("%s: output %f after decoding, want %f", orig.Type(), out, want)
+			}
+		}
+	}
+}
+
+func TestNEAT_AdoptRestoresTrackerAfterDecode(t *testing.T) {
+	n := NewNEAT(3, 4, 2)
+	n.addNode()
+	split := n.Connections[0]
+	for _, c := range n.Connections {
+		if !c.Enabled {
+			split = c
+		}
+	}
+	data, _ := n.MarshalBinary()
+
+	// A restarted process starts with an empty tracker
+	saved := innovations
+	defer func() { innovations = saved }()
+	innovations = &innovationTracker{
+		connections:    make(map[[2]int]int),
+		splits:         make(map[int]int),
+		nextInnovation: 1,
+		nextNode:       firstHiddenNode,
+	}
+
+	decoded, err := DecodeBinary(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if innovations.nextInnovation != 1 || len(innovations.connections) != 0 {
+		t.Fatal("Decoding changed the innovation tracker")
+	}
+
+	Adopt(decoded)
+	if node := innovations.split(split.Innovation); node != n.Hidden[0] {
+		t.Errorf("Splitting gene %d again made node %d, want %d", split.Innovation, node, n.Hidden[0])
+	}
+	last := n.Connections[len(n.Connections)-1].Innovation
+	if id := innovations.connection(n.Hidden[0], n.Hidden[0]); id <= last {
+		t.Errorf("New connection got innovation %d, already in use", id)
+	}
+}
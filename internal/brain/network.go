@@ -1,7 +1,6 @@
 package brain
 
 import (
-	"math"
 	"math/rand/v2"
 )
 
@@ -14,6 +13,17 @@ type Network struct {
 	weights1 []float64
 	// weights2 connects hidden -> output (size: HiddenSize * OutputSize)
 	weights2 []float64
+	// Biases of the hidden and output neurons
+	bias1 []float64
+	bias2 []float64
+	// Per-neuron activation functions of the hidden and output layers
+	act1 []Activation
+	act2 []Activation
+	// Lifetime learning rule, see HebbianRule
+	rule HebbianRule
+	// Inherited weights, kept once Learn has changed weights1/weights2
+	genetic1 []float64
+	genetic2 []float64
 
 	// Elman recurrent memory: previous tick's hidden layer output
 	hiddenState []float64
@@ -21,6 +31,9 @@ type Network struct {
 	// Pre-allocated buffers to reduce GC pressure
 	hiddenBuffer []float64
 	outputBuffer []float64
+	lastInput    []float64 // Sensory inputs of the last FeedForward, for Learn
+
+	revision uint32 // Bumped when weights change, so Batch knows to repack
 }
 
 // totalInputSize returns the effective input size including recurrent context.
@@ -28,6 +41,8 @@ func (nn *Network) totalInputSize() int {
 	return nn.InputSize + nn.HiddenSize
 }
 
+// NewNetwork creates a network with random weights, zero biases and tanh
+// activations; mutation evolves the latter two.
 func NewNetwork(input, hidden, output int) *Network {
 	totalInput := input + hidden
 	nn := &Network{
@@ -36,6 +51,11 @@ func NewNetwork(input, hidden, output int) *Network {
 		OutputSize:   output,
 		weights1:     initWeights(totalInput * hidden),
 		weights2:     initWeights(hidden * output),
+		bias1:        make([]float64, hidden),
+		bias2:        make([]float64, output),
+		act1:         make([]Activation, hidden), // Zero value is Tanh
+		act2:         make([]Activation, output),
+		rule:         randomHebbianRule(),
 		hiddenState:  make([]float64, hidden),
 		hiddenBuffer: make([]float64, hidden),
 		outputBuffer: make([]float64, output),
@@ -50,7 +70,7 @@ func (nn *Network) FeedForward(inputs []float64) []float64 {
 	// (input + hiddenState) -> hidden
 	// Layout: j * HiddenSize + i, where j indexes the total input
 	for i := 0; i < nn.HiddenSize; i++ {
-		sum := 0.0
+		sum := nn.bias1[i]
 		// Sensory inputs
 		for j := 0; j < nn.InputSize; j++ {
 			sum += inputs[j] * nn.weights1[j*nn.HiddenSize+i]
@@ -59,24 +79,50 @@ func (nn *Network) FeedForward(inputs []float64) []float64 {
 		for j := nn.InputSize; j < totalIn; j++ {
 			sum += nn.hiddenState[j-nn.InputSize] * nn.weights1[j*nn.HiddenSize+i]
 		}
-		nn.hiddenBuffer[i] = math.Tanh(sum)
+		nn.hiddenBuffer[i] = nn.act1[i].Apply(sum)
 	}
 
 	// Save hidden output as state for next tick
 	copy(nn.hiddenState, nn.hiddenBuffer)
+	if nn.lastInput == nil {
+		nn.lastInput = make([]float64, nn.InputSize)
+	}
+	copy(nn.lastInput, inputs)
 
 	// hidden -> output
 	for i := 0; i < nn.OutputSize; i++ {
-		sum := 0.0
+		sum := nn.bias2[i]
 		for j := 0; j < nn.HiddenSize; j++ {
 			sum += nn.hiddenBuffer[j] * nn.weights2[j*nn.OutputSize+i]
 		}
-		nn.outputBuffer[i] = math.Tanh(sum)
+		nn.outputBuffer[i] = nn.act2[i].Apply(sum)
 	}
 
 	return nn.outputBuffer
 }
 
+func (nn *Network) Shape() (input, hidden, output int) {
+	return nn.InputSize, nn.HiddenSize, nn.OutputSize
+}
+
+func (nn *Network) Type() string {
+	return TypeElman
+}
+
+// Offspring resizes a copy of the network, or crosses it over with a mate of
+// the same type and input/output shape. Learned weights are not inherited
+// unless consolidated.
+func (nn *Network) Offspring(mate Brain, layers []int) Brain {
+	if other, ok := mate.(*Network); ok && other.InputSize == nn.InputSize && other.OutputSize == nn.OutputSize {
+		return nn.inheritable().CrossoverWithResize(other.inheritable(), layers[0])
+	}
+	return nn.inheritable().CloneWithResize(layers[0])
+}
+
+func (nn *Network) Layers() int {
+	return 1
+}
+
 func (nn *Network) Clone() *Network {
 	newNet := &Network{
 		InputSize:    nn.InputSize,
@@ -84,6 +130,11 @@ func (nn *Network) Clone() *Network {
 		OutputSize:   nn.OutputSize,
 		weights1:     make([]float64, len(nn.weights1)),
 		weights2:     make([]float64, len(nn.weights2)),
+		bias1:        make([]float64, len(nn.bias1)),
+		bias2:        make([]float64, len(nn.bias2)),
+		act1:         make([]Activation, len(nn.act1)),
+		act2:         make([]Activation, len(nn.act2)),
+		rule:         nn.rule,
 		hiddenState:  make([]float64, nn.HiddenSize), // Zeroed — newborns have no memory
 		hiddenBuffer: make([]float64, nn.HiddenSize),
 		outputBuffer: make([]float64, nn.OutputSize),
@@ -91,6 +142,10 @@ func (nn *Network) Clone() *Network {
 
 	copy(newNet.weights1, nn.weights1)
 	copy(newNet.weights2, nn.weights2)
+	copy(newNet.bias1, nn.bias1)
+	copy(newNet.bias2, nn.bias2)
+	copy(newNet.act1, nn.act1)
+	copy(newNet.act2, nn.act2)
 
 	return newNet
 }
@@ -108,6 +163,11 @@ func (nn *Network) Crossover(other *Network) *Network {
 	}
 	child.weights1 = crossoverSlice(nn.weights1, other.weights1)
 	child.weights2 = crossoverSlice(nn.weights2, other.weights2)
+	child.bias1 = crossoverSlice(nn.bias1, other.bias1)
+	child.bias2 = crossoverSlice(nn.bias2, other.bias2)
+	child.act1 = crossoverActivations(nn.act1, other.act1)
+	child.act2 = crossoverActivations(nn.act2, other.act2)
+	child.rule = nn.rule.crossover(other.rule)
 	return child
 }
 
@@ -144,6 +204,14 @@ func (nn *Network) CloneWithResize(newHiddenSize int) *Network {
 		}
 	}
 
+	// Neuron parameters: surviving hidden neurons and all outputs keep theirs,
+	// new hidden neurons start with zero bias and tanh
+	copy(newNet.bias1, nn.bias1[:minH])
+	copy(newNet.act1, nn.act1[:minH])
+	copy(newNet.bias2, nn.bias2)
+	copy(newNet.act2, nn.act2)
+	newNet.rule = nn.rule
+
 	return newNet
 }
 
@@ -226,6 +294,19 @@ func (nn *Network) CrossoverWithResize(other *Network, childHiddenSize int) *Net
 		}
 	}
 
+	// Hidden neuron parameters travel with the neuron, like its weights
+	for i := 0; i < childHiddenSize; i++ {
+		inNN, inOther := i < nn.HiddenSize, i < other.HiddenSize
+		if inNN && (!inOther || rand.Float64() < 0.5) {
+			child.bias1[i], child.act1[i] = nn.bias1[i], nn.act1[i]
+		} else if inOther {
+			child.bias1[i], child.act1[i] = other.bias1[i], other.act1[i]
+		}
+	}
+	child.bias2 = crossoverSlice(nn.bias2, other.bias2)
+	child.act2 = crossoverActivations(nn.act2, other.act2)
+	child.rule = nn.rule.crossover(other.rule)
+
 	return child
 }
 
@@ -244,6 +325,12 @@ func crossoverSlice(a, b []float64) []float64 {
 func (nn *Network) Mutate(rate, strength float64) {
 	mutateSlice(nn.weights1, rate, strength)
 	mutateSlice(nn.weights2, rate, strength)
+	mutateSlice(nn.bias1, rate, strength)
+	mutateSlice(nn.bias2, rate, strength)
+	mutateActivations(nn.act1, rate*activationMutationRate, hiddenActivations)
+	mutateActivations(nn.act2, rate*activationMutationRate, outputActivations)
+	nn.rule.mutate(rate, strength)
+	nn.revision++
 }
 
 func initWeights(size int) []float64 {
@@ -109,3 +109,116 @@ func TestNetwork_CloneWithResize(t *testing.T) {
 		t.Errorf("Expected 2 outputs, got %d", len(out))
 	}
 }
+
+func TestNetwork_BinaryRoundTrip(t *testing.T) {
+	nn := NewNetwork(4, 5, 2)
+
+	data, err := nn.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary: %v", err)
+	}
+
+	var restored Network
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary: %v", err)
+	}
+
+	if restored.InputSize != 4 || restored.HiddenSize != 5 || restored.OutputSize != 2 {
+		t.Fatalf("Shape mismatch: %d/%d/%d", restored.InputSize, restored.HiddenSize, restored.OutputSize)
+	}
+	for i := range nn.weights1 {
+		if restored.weights1[i] != nn.weights1[i] {
+			t.Fatalf("weights1[%d]: got %f, want %f", i, restored.weights1[i], nn.weights1[i])
+		}
+	}
+	for i := range nn.weights2 {
+		if restored.weights2[i] != nn.weights2[i] {
+			t.Fatalf("weights2[%d]: got %f, want %f", i, restored.weights2[i], nn.weights2[i])
+		}
+	}
+}
+
+func TestNetwork_BiasAndActivationsInherited(t *testing.T) {
+	nn := NewNetwork(2, 3, 1)
+	for i := range nn.weights1 {
+		nn.weights1[i] = 0
+	}
+	for i := range nn.weights2 {
+		nn.weights2[i] = 0
+	}
+	nn.bias2[0] = 0.5
+	nn.act2[0] = Sigmoid
+	nn.bias1[1] = -2
+	nn.act1[1] = Gaussian
+
+	// With no signal, the output is the activated bias: a constant drift
+	want := Sigmoid.Apply(0.5)
+	if out := nn.FeedForward([]float64{0, 0})[0]; out != want {
+		t.Fatalf("Expected output %f from bias alone, got %f", want, out)
+	}
+
+	resized := nn.CloneWithResize(5)
+	if resized.bias1[1] != -2 || resized.act1[1] != Gaussian || resized.act1[4] != Tanh {
+		t.Errorf("Resize lost hidden neuron parameters: %v %v", resized.bias1, resized.act1)
+	}
+	if resized.bias2[0] != 0.5 || resized.act2[0] != Sigmoid {
+		t.Errorf("Resize lost output neuron parameters")
+	}
+
+	child := nn.CrossoverWithResize(resized, 4)
+	if child.bias1[1] != -2 || child.act1[1] != Gaussian {
+		t.Errorf("Crossover of identical neurons changed them: %v %v", child.bias1, child.act1)
+	}
+
+	data, _ := nn.MarshalBinary()
+	var restored Network
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary: %v", err)
+	}
+	if restored.bias2[0] != 0.5 || restored.act2[0] != Sigmoid || restored.act1[1] != Gaussian {
+		t.Errorf("Binary round trip lost neuron parameters")
+	}
+}
+
+func TestNetwork_LearnedWeightsNotInherited(t *testing.T) {
+	nn := NewNetwork(2, 3, 1)
+	nn.rule = HebbianRule{Rate: 0.5, A: 1}
+	inherited := append([]float64(nil), nn.weights2...)
+
+	nn.FeedForward([]float64{1, -1})
+	nn.Learn(1.0)
+	if nn.weights2[0] == inherited[0] {
+		t.Fatal("Learn did not change weights")
+	}
+
+	child := nn.Offspring(nil, []int{3}).(*Network)
+	for i := range inherited {
+		if child.weights2[i] != inherited[i] {
+			t.Fatalf("Child inherited learned weight %d: %f, want %f", i, child.weights2[i], inherited[i])
+		}
+	}
+
+	// Lamarckian mode: learned weights become heritable
+	nn.Consolidate()
+	child = nn.Offspring(nil, []int{3}).(*Network)
+	if child.weights2[0] != nn.weights2[0] {
+		t.Errorf("Consolidated weights not inherited: %f vs %f", child.weights2[0], nn.weights2[0])
+	}
+}
+
+func TestNetwork_UnmarshalBinaryChecksShape(t *testing.T) {
+	data, _ := NewNetwork(4, 5, 2).MarshalBinary()
+
+	// 20000x20000 would need gigabytes; a short header must not allocate them
+	huge := append([]byte(nil), data[:7]...)
+	huge[3], huge[4] = 0x20, 0x4e
+	var nn Network
+	if err := nn.UnmarshalBinary(huge); err == nil {
+		t.Errorf("accepted a 20000 neuron layer")
+	}
+	for _, bad := range [][]byte{data[:len(data)-1], append(append([]byte(nil), data...), 0)} {
+		if err := nn.UnmarshalBinary(bad); err == nil {
+			t.Errorf("accepted %d bytes for a network of %d", len(bad), len(data))
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package brain
+
+import (
+	"math"
+	"math/rand/v2"
+)
+
+// Plastic brains change their weights during a creature's life.
+type Plastic interface {
+	// Learn adjusts weights after a tick, scaled by reward (e.g. the energy
+	// gained or lost).
+	Learn(reward float64)
+	// Consolidate makes learned weights heritable (Lamarckian inheritance).
+	// Otherwise offspring inherit the weights the brain was born with.
+	Consolidate()
+}
+
+// HebbianRule is the evolvable plasticity rule. Each sensory->hidden and
+// hidden->output weight changes by
+//
+//	Rate * reward * (A*pre*post + B*pre + C*post + D)
+//
+// where pre and post are the activations on either side of the weight.
+type HebbianRule struct {
+	Rate       float64
+	A, B, C, D float64
+}
+
+func randomHebbianRule() HebbianRule {
+	return HebbianRule{
+		Rate: rand.Float64() * 0.1,
+		A:    rand.Float64()*2.0 - 1.0,
+		B:    rand.Float64()*2.0 - 1.0,
+		C:    rand.Float64()*2.0 - 1.0,
+		D:    rand.Float64()*2.0 - 1.0,
+	}
+}
+
+func (h HebbianRule) crossover(other HebbianRule) HebbianRule {
+	pick := func(a, b float64) float64 {
+		if rand.Float64() < 0.5 {
+			return a
+		}
+		return b
+	}
+	return HebbianRule{
+		Rate: pick(h.Rate, other.Rate),
+		A:    pick(h.A, other.A),
+		B:    pick(h.B, other.B),
+		C:    pick(h.C, other.C),
+		D:    pick(h.D, other.D),
+	}
+}
+
+func (h *HebbianRule) mutate(rate, strength float64) {
+	for _, v := range []*float64{&h.A, &h.B, &h.C, &h.D} {
+		if rand.Float64() < rate {
+			*v = math.Max(-1, math.Min(1, *v+rand.NormFloat64()*strength))
+		}
+	}
+	// The learning rate takes smaller steps: it multiplies every change
+	if rand.Float64() < rate {
+		h.Rate = math.Max(0, math.Min(1, h.Rate+rand.NormFloat64()*strength*0.1))
+	}
+}
+
+func (h HebbianRule) delta(reward, pre, post float64) float64 {
+	return h.Rate * reward * (h.A*pre*post + h.B*pre + h.C*post + h.D)
+}
+
+// Learn applies the Hebbian rule to the activations of the last FeedForward.
+// The first change keeps a copy of the inherited weights for offspring.
+func (nn *Network) Learn(reward float64) {
+	if reward == 0 || nn.rule.Rate == 0 || nn.lastInput == nil {
+		return
+	}
+	if nn.genetic1 == nil {
+		nn.genetic1 = append([]float64(nil), nn.weights1...)
+		nn.genetic2 = append([]float64(nil), nn.weights2...)
+	}
+
+	// Sensory -> hidden (recurrent context weights stay innate)
+	for j := 0; j < nn.InputSize; j++ {
+		pre := nn.lastInput[j]
+		for i := 0; i < nn.HiddenSize; i++ {
+			idx := j*nn.HiddenSize + i
+			nn.weights1[idx] = clampWeight(nn.weights1[idx] + nn.rule.delta(reward, pre, nn.hiddenBuffer[i]))
+		}
+	}
+	// Hidden -> output
+	for j := 0; j < nn.HiddenSize; j++ {
+		pre := nn.hiddenBuffer[j]
+		for i := 0; i < nn.OutputSize; i++ {
+			idx := j*nn.OutputSize + i
+			nn.weights2[idx] = clampWeight(nn.weights2[idx] + nn.rule.delta(reward, pre, nn.outputBuffer[i]))
+		}
+	}
+	nn.revision++
+}
+
+func (nn *Network) Consolidate() {
+	nn.genetic1, nn.genetic2 = nil, nil
+}
+
+// inheritable returns the network offspring should inherit from: itself, or
+// a view with the weights it was born with if it has learned since.
+func (nn *Network) inheritable() *Network {
+	if nn.genetic1 == nil {
+		return nn
+	}
+	innate := *nn
+	innate.weights1, innate.weights2 = nn.genetic1, nn.genetic2
+	innate.genetic1, innate.genetic2 = nil, nil
+	return &innate
+}
+
+func clampWeight(w float64) float64 {
+	return math.Max(-5.0, math.Min(5.0, w))
+}
@@ -4,29 +4,66 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	HTTPPort             string
-	DBPath               string
+	HTTPPort       string
+	StorageBackend string // "sqlite", "dir" or "memory"
+	DBPath         string // SQLite file, or directory for the "dir" backend
+
+	// Snapshots
+	SnapshotInterval time.Duration
+	SnapshotFormat   string        // "json" or "binary" (gzip-compressed, much smaller)
+	RetainHourlyFor  time.Duration // Keep one snapshot per hour for this long
+	RetainDailyFor   time.Duration // Then one per day for this long
+	RetainWeeklyFor  time.Duration // Then one per week for this long (0 = forever)
+
+	// Rewind buffer for the web client
+	RewindLength time.Duration // How far back clients can rewind (0 = disabled)
+	RewindFPS    int           // Frames kept per second of history
+
 	WorldWidth           float64
 	WorldHeight          float64
 	InitialPop           int
+	SeedGenomes          []string // Genome codes the initial population cycles through; empty = random
 	FoodCount            int
 	FoodEnergy           float64
 	MoveCost             float64
 	SpeedFactor          float64
-	InputSize  int
-	OutputSize int
+	VisionRayCount       int      // Rays cast by the rays sensor
+	VisionRayCost        float64  // BMR per ray per radian of field of view
+	Sensors              []string // Brain inputs, see world.SensorNames; their count is the input size
+	Motors               string   // "direct" (velocity outputs) or "thrust" (heading, inertia, thrust/turn outputs)
+	Strafe               bool     // Thrust motors: extra sideways output
+	Drag                 float64  // Thrust motors: velocity lost per tick by a creature of mass 1
+	TurnRate             float64  // Thrust motors: radians per tick at full turn
 	EatRadius            float64
 	MutationRate         float64
 	MutationStrength     float64
+	AdaptiveMutation     bool     // Genomes evolve their own rate and strength, starting from the above
+	MutationRange        float64  // AdaptiveMutation: furthest factor either way from the above
+	MarkerRate           float64  // Chance a neutral marker mutates per birth: the molecular clock
+	Dominance            []string // Per-locus dominance overrides, see entity.SetDominance
+	Linkage              bool     // Loci on a chromosome are inherited together
+	RecombinationRate    float64  // Linkage: crossovers per chromosome per meiosis
 	ReproduceThreshold   float64
 	AsexualThresholdMult float64
 	MaxAge               float64
 
+	// Brain-driven actions, see world.ActionEat etc.
+	Actions         []string // Actions with a brain output; the rest are automatic
+	ActionThreshold float64  // An action output above this tries the action
+	EatCost         float64  // Energy per tick of trying, for each action
+	AttackCost      float64
+	MateCost        float64
+	FleeCost        float64
+	SignalCost      float64
+	FleeBurst       float64 // Flee dash length in multiples of max speed
+
 	// Ecosystem Control
 	FoodSpawnChance    float64
 	CrowdingDistance   float64
@@ -43,7 +80,13 @@ type Config struct {
 	InbreedingPenalty   float64 // Energy reduction fraction for inbred offspring
 
 	// Advanced bio
+	BrainType          string  // "elman" (fixed topology), "neat" (evolving topology) or "deep" (multi-layer)
+	BrainDepth         int     // Hidden layers of deep brains
+	BrainGated         bool    // Deep brains: GRU memory cell instead of Elman recurrence
 	BrainCostPerNeuron float64 // Energy cost per hidden neuron per tick
+	Plasticity         bool    // Lifetime Hebbian learning, rewarded by energy gain
+	Lamarckian         bool    // Offspring inherit learned weights instead of inherited ones
+	BatchBrains        bool    // Sense for all creatures first, then evaluate brains in one float32 batch
 	PheromoneDeposit   float64 // Amount of pheromone deposited per tick
 	PheromoneDecay     float64 // Decay factor per tick (0.98 = 2% decay)
 }
@@ -54,24 +97,56 @@ func Load() *Config {
 	}
 
 	return &Config{
-		HTTPPort:             getEnv("HTTP_PORT", "8080"),
-		DBPath:               getEnv("DB_PATH", "./database.db"),
+		HTTPPort:       getEnv("HTTP_PORT", "8080"),
+		StorageBackend: getEnv("STORAGE_BACKEND", "sqlite"),
+		DBPath:         getEnv("DB_PATH", "./database.db"),
+
+		SnapshotInterval: getEnvAsDuration("SNAPSHOT_INTERVAL", 15*time.Minute),
+		SnapshotFormat:   getEnv("SNAPSHOT_FORMAT", "json"),
+		RetainHourlyFor:  getEnvAsDuration("RETAIN_HOURLY_FOR", 24*time.Hour),
+		RetainDailyFor:   getEnvAsDuration("RETAIN_DAILY_FOR", 30*24*time.Hour),
+		RetainWeeklyFor:  getEnvAsDuration("RETAIN_WEEKLY_FOR", 0),
+
+		RewindLength: getEnvAsDuration("REWIND_LENGTH", 3*time.Minute),
+		RewindFPS:    getEnvAsInt("REWIND_FPS", 10),
+
 		WorldWidth:           getEnvAsFloat("WORLD_WIDTH", 800.0),
 		WorldHeight:          getEnvAsFloat("WORLD_HEIGHT", 600.0),
 		InitialPop:           getEnvAsInt("INITIAL_POP", 20),
+		SeedGenomes:          getEnvAsList("SEED_GENOMES", ""),
 		FoodCount:            getEnvAsInt("FOOD_COUNT", 50),
 		FoodEnergy:           getEnvAsFloat("FOOD_ENERGY", 70.0),
 		MoveCost:             getEnvAsFloat("MOVE_COST", 0.05),
 		SpeedFactor:          getEnvAsFloat("SPEED_FACTOR", 1.5),
-		InputSize:  getEnvAsInt("INPUT_SIZE", 11),
-		OutputSize: getEnvAsInt("OUTPUT_SIZE", 2),
+		VisionRayCount:       getEnvAsInt("VISION_RAY_COUNT", 5),
+		VisionRayCost:        getEnvAsFloat("VISION_RAY_COST", 0.001),
+		Sensors:              getEnvAsList("SENSORS", "food,creature,energy,target_diet,walls,pheromone"),
+		Motors:               getEnv("MOTORS", "direct"),
+		Strafe:               getEnvAsBool("STRAFE", false),
+		Drag:                 getEnvAsFloat("DRAG", 0.1),
+		TurnRate:             getEnvAsFloat("TURN_RATE", 0.2),
 		EatRadius:            getEnvAsFloat("EAT_RADIUS", 10.0),
 		MutationRate:         getEnvAsFloat("MUTATION_RATE", 0.1),
 		MutationStrength:     getEnvAsFloat("MUTATION_STRENGTH", 0.2),
+		AdaptiveMutation:     getEnvAsBool("ADAPTIVE_MUTATION", false),
+		MutationRange:        getEnvAsFloat("ADAPTIVE_MUTATION_RANGE", 4.0),
+		MarkerRate:           getEnvAsFloat("MARKER_MUTATION_RATE", 0.5),
+		Dominance:            getEnvAsList("DOMINANCE", ""),
+		Linkage:              getEnvAsBool("LINKAGE", false),
+		RecombinationRate:    getEnvAsFloat("RECOMBINATION_RATE", 1.0),
 		ReproduceThreshold:   getEnvAsFloat("REPRODUCE_THRESHOLD", 150.0),
 		AsexualThresholdMult: getEnvAsFloat("ASEXUAL_THRESHOLD_MULT", 1.5),
 		MaxAge:               getEnvAsFloat("MAX_AGE", 10000.0),
 
+		Actions:         getEnvAsList("ACTIONS", ""),
+		ActionThreshold: getEnvAsFloat("ACTION_THRESHOLD", 0.0),
+		EatCost:         getEnvAsFloat("EAT_COST", 0.01),
+		AttackCost:      getEnvAsFloat("ATTACK_COST", 0.05),
+		MateCost:        getEnvAsFloat("MATE_COST", 0.02),
+		FleeCost:        getEnvAsFloat("FLEE_COST", 0.5),
+		SignalCost:      getEnvAsFloat("SIGNAL_COST", 0.01),
+		FleeBurst:       getEnvAsFloat("FLEE_BURST", 3.0),
+
 		FoodSpawnChance:    getEnvAsFloat("FOOD_SPAWN_CHANCE", 0.05), // ~3 food/sec at 60fps
 		CrowdingDistance:   getEnvAsFloat("CROWDING_DISTANCE", 50.0),
 		CrowdingMultiplier: getEnvAsFloat("CROWDING_MULTIPLIER", 0.1), // +10% BMR per neighbor
@@ -85,7 +160,13 @@ func Load() *Config {
 		InbreedingThreshold: getEnvAsFloat("INBREEDING_THRESHOLD", 0.15),
 		InbreedingPenalty:   getEnvAsFloat("INBREEDING_PENALTY", 0.2),
 
+		BrainType:          getEnv("BRAIN_TYPE", "elman"),
+		BrainDepth:         getEnvAsInt("BRAIN_DEPTH", 2),
+		BrainGated:         getEnvAsBool("BRAIN_GATED", false),
 		BrainCostPerNeuron: getEnvAsFloat("BRAIN_COST_PER_NEURON", 0.005),
+		Plasticity:         getEnvAsBool("PLASTICITY", false),
+		Lamarckian:         getEnvAsBool("LAMARCKIAN", false),
+		BatchBrains:        getEnvAsBool("BATCH_BRAINS", false),
 		PheromoneDeposit:   getEnvAsFloat("PHEROMONE_DEPOSIT", 0.1),
 		PheromoneDecay:     getEnvAsFloat("PHEROMONE_DECAY", 0.98),
 	}
@@ -113,3 +194,30 @@ func getEnvAsFloat(key string, defaultVal float64) float64 {
 	}
 	return defaultVal
 }
+
+func getEnvAsBool(key string, defaultVal bool) bool {
+	valueStr := getEnv(key, "")
+	if value, err := strconv.ParseBool(valueStr); err == nil {
+		return value
+	}
+	return defaultVal
+}
+
+// getEnvAsList splits a comma-separated value, ignoring blank entries.
+func getEnvAsList(key, defaultVal string) []string {
+	var list []string
+	for _, item := range strings.Split(getEnv(key, defaultVal), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func getEnvAsDuration(key string, defaultVal time.Duration) time.Duration {
+	valueStr := getEnv(key, "")
+	if value, err := time.ParseDuration(valueStr); err == nil {
+		return value
+	}
+	return defaultVal
+}
@@ -0,0 +1,118 @@
+package entity
+
+import (
+	"math"
+	"math/rand/v2"
+	"sort"
+)
+
+// Meiosis is how parents pass their alleles on.
+type Meiosis struct {
+	Linkage           bool    // Loci on a chromosome are inherited together; off, every locus assorts independently
+	RecombinationRate float64 // Linkage: expected crossovers along a whole chromosome
+}
+
+// chromosomes lists the loci of each chromosome in position order.
+var chromosomes = func() [][]int {
+	byChromosome := map[int][]int{}
+	for i, l := range Loci {
+		if l.Chromosome > 0 && !l.Haploid {
+			byChromosome[l.Chromosome] = append(byChromosome[l.Chromosome], i)
+		}
+	}
+	var out [][]int
+	for c := 1; len(byChromosome) > 0; c++ {
+		if loci, ok := byChromosome[c]; ok {
+			sort.Slice(loci, func(a, b int) bool { return Loci[loci[a]].Position < Loci[loci[b]].Position })
+			out = append(out, loci)
+			delete(byChromosome, c)
+		}
+	}
+	return out
+}()
+
+// recombination is the chance of an odd number of crossovers between two
+// positions, from Haldane's map function.
+func (m Meiosis) recombination(from, to float64) float64 {
+	return 0.5 * (1 - math.Exp(-2*m.RecombinationRate*math.Abs(to-from)))
+}
+
+// gamete picks one copy of every diploid locus. With linkage, each
+// chromosome starts on a random copy and switches at crossovers.
+func (g *Genome) gamete(m Meiosis) [NumLoci]float64 {
+	var out [NumLoci]float64
+	for i := range Loci {
+		out[i] = g.Alleles[i][rand.IntN(2)]
+	}
+	if !m.Linkage {
+		return out
+	}
+	for _, loci := range chromosomes {
+		strand := rand.IntN(2)
+		for k, i := range loci {
+			if k > 0 && rand.Float64() < m.recombination(Loci[loci[k-1]].Position, Loci[i].Position) {
+				strand = 1 - strand
+			}
+			out[i] = g.Alleles[i][strand]
+		}
+	}
+	return out
+}
+
+// LinkageDisequilibrium returns r², the squared correlation between the
+// alleles of loci i and j that share a chromosome copy, over every copy in
+// genomes. Near 0 the loci are inherited independently; at 1 one predicts the
+// other. Loci without variation give 0.
+func LinkageDisequilibrium(genomes []Genome, i, j int) float64 {
+	var n, si, sj, sii, sjj, sij float64
+	for _, g := range genomes {
+		for k := 0; k < 2; k++ {
+			a, b := g.Alleles[i][k], g.Alleles[j][k]
+			n++
+			si += a
+			sj += b
+			sii += a * a
+			sjj += b * b
+			sij += a * b
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	vi, vj := sii/n-(si/n)*(si/n), sjj/n-(sj/n)*(sj/n)
+	if vi <= 1e-12 || vj <= 1e-12 {
+		return 0
+	}
+	cov := sij/n - (si/n)*(sj/n)
+	return cov * cov / (vi * vj)
+}
+
+// MeanLinkageDisequilibrium averages r² over pairs of diploid loci on the
+// same chromosome and over pairs on different chromosomes. With linkage the
+// first stays higher, since crossovers rarely split close loci.
+func MeanLinkageDisequilibrium(genomes []Genome) (linked, unlinked float64) {
+	var nLinked, nUnlinked int
+	for i := range Loci {
+		for j := i + 1; j < NumLoci; j++ {
+			li, lj := &Loci[i], &Loci[j]
+			if li.Haploid || lj.Haploid {
+				continue
+			}
+			r2 := LinkageDisequilibrium(genomes, i, j)
+			if li.Chromosome > 0 && li.Chromosome == lj.Chromosome {
+				linked += r2
+				nLinked++
+			} else {
+				unlinked += r2
+				nUnlinked++
+			}
+		}
+	}
+	if nLinked > 0 {
+		linked /= float64(nLinked)
+	}
+	if nUnlinked > 0 {
+		unlinked /= float64(nUnlinked)
+	}
+	return linked, unlinked
+}
@@ -0,0 +1,97 @@
+package entity
+
+import (
+	"math"
+	"testing"
+)
+
+// phased returns a genome whose first copy of every diploid locus holds 1 and
+// second copy 2, so a gamete shows which copy each locus came from.
+func phased() Genome {
+	var g Genome
+	for i := range Loci {
+		g.Alleles[i] = [2]float64{1, 2}
+	}
+	return g
+}
+
+func TestMeiosis_LinkedLociRecombineByDistance(t *testing.T) {
+	g := phased()
+	const n = 20000
+	splits := func(m Meiosis, i, j int) float64 {
+		count := 0
+		for k := 0; k < n; k++ {
+			gamete := g.gamete(m)
+			if gamete[i] != gamete[j] {
+				count++
+			}
+		}
+		return float64(count) / n
+	}
+
+	linked := Meiosis{Linkage: true, RecombinationRate: 1}
+	want := linked.recombination(Loci[LocusSize].Position, Loci[LocusDiet].Position)
+	if got := splits(linked, LocusSize, LocusDiet); math.Abs(got-want) > 0.02 {
+		t.Errorf("size and diet split in %.3f of gametes, want %.3f", got, want)
+	}
+	if got := splits(linked, LocusSize, LocusSense); math.Abs(got-0.5) > 0.02 {
+		t.Errorf("loci on different chromosomes split in %.3f of gametes, want 0.5", got)
+	}
+	if got := splits(Meiosis{}, LocusSize, LocusDiet); math.Abs(got-0.5) > 0.02 {
+		t.Errorf("without linkage size and diet split in %.3f of gametes, want 0.5", got)
+	}
+	if got := splits(Meiosis{Linkage: true}, LocusSize, LocusSpeed); got != 0 {
+		t.Errorf("chromosome split without crossovers in %.3f of gametes", got)
+	}
+}
+
+func TestChromosomes_HoldEveryDiploidLocusInOrder(t *testing.T) {
+	seen := map[int]bool{}
+	for _, loci := range chromosomes {
+		for k, i := range loci {
+			if seen[i] || Loci[i].Haploid || Loci[i].Chromosome != Loci[loci[0]].Chromosome {
+				t.Errorf("locus %s misplaced", Loci[i].Name)
+			}
+			if k > 0 && Loci[i].Position < Loci[loci[k-1]].Position {
+				t.Errorf("locus %s out of order", Loci[i].Name)
+			}
+			seen[i] = true
+		}
+	}
+	for i, l := range Loci {
+		if l.Chromosome > 0 && !l.Haploid && !seen[i] {
+			t.Errorf("locus %s missing from its chromosome", l.Name)
+		}
+	}
+}
+
+func TestLinkageDisequilibrium(t *testing.T) {
+	// Two haplotypes, big carnivore and small herbivore: fully associated
+	var pop []Genome
+	for k := 0; k < 10; k++ {
+		pop = append(pop, genome(map[int][2]float64{
+			LocusSize:  {2, 0.5},
+			LocusDiet:  {0.9, 0.1},
+			LocusSense: {100, 100},
+		}))
+	}
+	if r2 := LinkageDisequilibrium(pop, LocusSize, LocusDiet); math.Abs(r2-1) > 1e-9 {
+		t.Errorf("associated loci r² = %f, want 1", r2)
+	}
+	if r2 := LinkageDisequilibrium(pop, LocusSize, LocusSense); r2 != 0 {
+		t.Errorf("invariant locus r² = %f, want 0", r2)
+	}
+
+	// Many generations of free recombination break the association up
+	m := Meiosis{}
+	for gen := 0; gen < 20; gen++ {
+		next := make([]Genome, len(pop))
+		for k := range next {
+			next[k] = pop[k].Crossover(pop[(k+gen+1)%len(pop)], m)
+		}
+		pop = next
+	}
+	if r2 := LinkageDisequilibrium(pop, LocusSize, LocusDiet); r2 > 0.5 {
+		t.Errorf("r² still %f after 20 generations of recombination", r2)
+	}
+}
@@ -0,0 +1,175 @@
+package entity
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"strconv"
+	"strings"
+
+	"evo-sim/internal/brain"
+)
+
+// Genome codes are short strings carrying a genome, and optionally a brain,
+// for pasting into chat or config files: "evo1." then unpadded base64url of
+// the payload and its CRC-32. Version 1 payloads hold
+//
+//	flags byte (1 = has brain)
+//	uvarint locus count, then each locus in Loci order as float32 alleles,
+//	  one for haploid loci and two for the rest
+//	uvarint length and the Sensors string
+//	with a brain: uvarint length and its MarshalBinary
+//
+// Loci are stored by position, so the table is only ever appended to; codes
+// with loci a build doesn't know are refused, and missing ones get defaults.
+const (
+	codePrefix  = "evo"
+	codeVersion = 1
+	codeBrain   = 1 << 0
+)
+
+var errCorruptCode = errors.New("genome code is corrupt")
+
+// EncodeCode returns the genome code of g, including b if it is non-nil.
+// Alleles are rounded to float32.
+func EncodeCode(g Genome, b brain.Brain) (string, error) {
+	var flags byte
+	var brainData []byte
+	if b != nil {
+		var err error
+		if brainData, err = b.MarshalBinary(); err != nil {
+			return "", err
+		}
+		flags |= codeBrain
+	}
+
+	buf := []byte{flags}
+	buf = binary.AppendUvarint(buf, NumLoci)
+	for i, l := range Loci {
+		buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(float32(g.Alleles[i][0])))
+		if !l.Haploid {
+			buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(float32(g.Alleles[i][1])))
+		}
+	}
+	buf = binary.AppendUvarint(buf, uint64(len(g.Sensors)))
+	buf = append(buf, g.Sensors...)
+	if b != nil {
+		buf = binary.AppendUvarint(buf, uint64(len(brainData)))
+		buf = append(buf, brainData...)
+	}
+	buf = binary.LittleEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf))
+
+	return codePrefix + strconv.Itoa(codeVersion) + "." + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// DecodeCode reads a genome code. The brain is nil if the code has none.
+func DecodeCode(code string) (Genome, brain.Brain, error) {
+	head, body, ok := strings.Cut(strings.TrimSpace(code), ".")
+	if !ok || !strings.HasPrefix(head, codePrefix) {
+		return Genome{}, nil, errors.New("not a genome code")
+	}
+	if version, err := strconv.Atoi(head[len(codePrefix):]); err != nil || version != codeVersion {
+		return Genome{}, nil, fmt.Errorf("unsupported genome code version %q", head[len(codePrefix):])
+	}
+	data, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil || len(data) < 5 {
+		return Genome{}, nil, errCorruptCode
+	}
+	payload := data[:len(data)-4]
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(data[len(data)-4:]) {
+		return Genome{}, nil, errors.New("genome code checksum mismatch")
+	}
+
+	r := codeReader{data: payload}
+	flags := r.byte()
+	count := r.uvarint()
+	if count > NumLoci {
+		return Genome{}, nil, fmt.Errorf("genome code has %d loci, this build knows %d", count, NumLoci)
+	}
+	g := DefaultGenome()
+	for i := 0; i < int(count); i++ {
+		l := &Loci[i]
+		a1 := l.clamp(r.float32())
+		a2 := a1
+		if !l.Haploid {
+			a2 = l.clamp(r.float32())
+		}
+		g.Alleles[i] = [2]float64{a1, a2}
+	}
+	g.Sensors = string(r.bytes())
+
+	var b brain.Brain
+	if flags&codeBrain != 0 {
+		brainData := r.bytes()
+		if r.err == nil {
+			if b, err = brain.DecodeBinary(brainData); err != nil {
+				return Genome{}, nil, fmt.Errorf("genome code brain: %w", err)
+			}
+		}
+	}
+	if r.err != nil || len(r.data) != 0 {
+		return Genome{}, nil, errCorruptCode
+	}
+	return g, b, nil
+}
+
+// codeReader consumes a payload, remembering the first overrun.
+type codeReader struct {
+	data []byte
+	err  error
+}
+
+func (r *codeReader) take(n int) []byte {
+	if r.err != nil || n < 0 || n > len(r.data) {
+		r.err = errCorruptCode
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *codeReader) byte() byte {
+	if b := r.take(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *codeReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.err = errCorruptCode
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *codeReader) float32() float64 {
+	b := r.take(4)
+	if b == nil {
+		return 0
+	}
+	v := float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	if math.IsNaN(v) {
+		r.err = errCorruptCode
+		return 0
+	}
+	return v
+}
+
+func (r *codeReader) bytes() []byte {
+	n := r.uvarint()
+	if n > uint64(len(r.data)) {
+		r.err = errCorruptCode
+		return nil
+	}
+	return r.take(int(n))
+}
@@ -0,0 +1,87 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+
+	"evo-sim/internal/brain"
+)
+
+func TestCode_RoundTrip(t *testing.T) {
+	g := NewRandomGenome().MutateMarkers(1)
+	g.Sensors = "food,energy"
+
+	code, err := EncodeCode(g, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(code, "evo1.") || strings.ContainsAny(code, ",\n ") {
+		t.Errorf("code %q isn't a pasteable evo1 string", code)
+	}
+
+	got, b, err := DecodeCode(" " + code + "\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b != nil {
+		t.Errorf("brain decoded from a code without one")
+	}
+	if got.Sensors != g.Sensors {
+		t.Errorf("sensors %q, want %q", got.Sensors, g.Sensors)
+	}
+	for i, l := range Loci {
+		for k := 0; k < 2; k++ {
+			if want := float64(float32(g.Alleles[i][k])); got.Alleles[i][k] != want {
+				t.Errorf("locus %s allele %d = %v, want %v", l.Name, k, got.Alleles[i][k], want)
+			}
+		}
+	}
+}
+
+func TestCode_CarriesBrain(t *testing.T) {
+	net := brain.New(brain.Spec{Type: brain.TypeElman}, 4, []int{5}, 2)
+	code, err := EncodeCode(NewRandomGenome(), net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, b, err := DecodeCode(code)
+	if err != nil || b == nil {
+		t.Fatalf("brain lost: %v", err)
+	}
+
+	in := []float64{0.1, -0.2, 0.3, 0.4}
+	want, got := net.FeedForward(in), b.FeedForward(in)
+	for i := range want {
+		if want[i] != got[i] {
+			t.Fatalf("decoded brain outputs %v, want %v", got, want)
+		}
+	}
+}
+
+func TestCode_RejectsDamage(t *testing.T) {
+	code, err := EncodeCode(NewRandomGenome(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Flip one payload character
+	i := len("evo1.") + 10
+	flipped := []byte(code)
+	if flipped[i] == 'A' {
+		flipped[i] = 'B'
+	} else {
+		flipped[i] = 'A'
+	}
+
+	for name, bad := range map[string]string{
+		"typo":      string(flipped),
+		"truncated": code[:len(code)-6],
+		"version":   "evo9" + code[len("evo1"):],
+		"foreign":   "hello.world",
+		"empty":     "",
+	} {
+		if _, _, err := DecodeCode(bad); err == nil {
+			t.Errorf("%s code accepted", name)
+		}
+	}
+}
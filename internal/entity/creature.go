@@ -1,7 +1,9 @@
 package entity
 
 import (
+	"encoding/json"
 	"math"
+	"math/rand/v2"
 
 	"evo-sim/internal/brain"
 )
@@ -11,6 +13,8 @@ type Creature struct {
 	SpeciesID  int // Tracks the evolutionary lineage
 	Generation int
 	X, Y       float64
+	Heading    float64 // Radians
+	VX, VY     float64 // Velocity per tick
 	Energy     float64
 
 	// Phenotype (derived from Genome)
@@ -27,15 +31,52 @@ type Creature struct {
 
 	// Genotype
 	Genome Genome
-	Brain  *brain.Network
+	Brain  brain.Brain
+
+	input []float64 // Brain inputs, see SensorBuffer
 }
 
-func NewCreature(id int, x, y float64, inputSize, outputSize int, brainCostPerNeuron float64) *Creature {
-	genome := NewRandomGenome()
+// UnmarshalJSON restores the brain by its recorded type.
+func (c *Creature) UnmarshalJSON(data []byte) error {
+	type plain Creature
+	aux := struct {
+		*plain
+		Brain json.RawMessage
+	}{plain: (*plain)(c)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
 
+	c.Brain = nil
+	if len(aux.Brain) == 0 || string(aux.Brain) == "null" {
+		return nil
+	}
+	b, err := brain.DecodeJSON(aux.Brain)
+	if err != nil {
+		return err
+	}
+	c.Brain = b
+	return nil
+}
+
+func NewCreature(id int, x, y float64, spec brain.Spec, inputSize, outputSize int, brainCostPerNeuron, visionCost float64) *Creature {
+	return NewCreatureWithGenome(id, x, y, NewRandomGenome(), nil, spec, inputSize, outputSize, brainCostPerNeuron, visionCost)
+}
+
+// NewCreatureWithGenome creates a first-generation creature from a given
+// genome, e.g. one read from a genome code. A nil net gets a random brain of
+// spec.
+func NewCreatureWithGenome(id int, x, y float64, genome Genome, net brain.Brain, spec brain.Spec, inputSize, outputSize int, brainCostPerNeuron, visionCost float64) *Creature {
 	// Calculate Phenotype from Genotype
-	mass, speed, view, bmr, maxEnergy, reproThresh, isCarn, hiddenSize := genome.CalculateStats(brainCostPerNeuron)
-	net := brain.NewNetwork(inputSize, hiddenSize, outputSize)
+	mass, speed, view, bmr, maxEnergy, reproThresh, isCarn, _ := genome.CalculateStats(brainCostPerNeuron, visionCost)
+	layerCount := spec.Layers()
+	if net != nil {
+		layerCount = net.Layers()
+	}
+	layers, extraBMR := brainLayers(genome, layerCount, brainCostPerNeuron)
+	if net == nil {
+		net = brain.New(spec, inputSize, layers, outputSize)
+	}
 
 	return &Creature{
 		ID:         id,
@@ -43,13 +84,14 @@ func NewCreature(id int, x, y float64, inputSize, outputSize int, brainCostPerNe
 		Generation: 1,
 		X:          x,
 		Y:          y,
+		Heading:    rand.Float64() * 2 * math.Pi,
 		Energy:     maxEnergy * 0.5, // Start with 50% max energy
 
 		Size:                  genome.ExpressedSize(), // Using SizeGene directly as visual size for now
 		Mass:                  mass,
 		Speed:                 speed,
 		ViewRadius:            view,
-		BMR:                   bmr,
+		BMR:                   bmr + extraBMR,
 		MaxEnergy:             maxEnergy,
 		ReproductionThreshold: reproThresh,
 		IsCarnivore:           isCarn,
@@ -60,49 +102,31 @@ func NewCreature(id int, x, y float64, inputSize, outputSize int, brainCostPerNe
 	}
 }
 
-func (c *Creature) Update(foodX, foodY, enemyX, enemyY, targetIsCarnivore, terrainSpeedFactor, terrainEnergyFactor, worldW, worldH, maxAge, stressFactor, pheromone float64) {
-	// Inputs normalized relative to ViewRadius where possible
-	// 1-2: relative food pos
-	// 3-4: relative creature pos
-	// 5: current energy
-	// 6: target role
-	// 7-10: distances to walls
-	// 11: pheromone concentration
-
-	input := []float64{
-		(foodX - c.X) / c.ViewRadius,
-		(foodY - c.Y) / c.ViewRadius,
-		(enemyX - c.X) / c.ViewRadius,
-		(enemyY - c.Y) / c.ViewRadius,
-		c.Energy / c.MaxEnergy, // Normalize energy by MaxEnergy
-		targetIsCarnivore,
-		c.X / worldW,
-		(worldW - c.X) / worldW,
-		c.Y / worldH,
-		(worldH - c.Y) / worldH,
-		pheromone / 10.0, // Normalize pheromone (capped at 10.0)
-	}
+// Inputs returns what the creature sensed last, nil before its first tick.
+func (c *Creature) Inputs() []float64 {
+	return c.input
+}
 
-	output := c.Brain.FeedForward(input)
+// SensorBuffer returns the reusable brain input buffer, sized to n.
+func (c *Creature) SensorBuffer(n int) []float64 {
+	if len(c.input) != n {
+		c.input = make([]float64, n)
+	}
+	return c.input
+}
 
+// Act moves the creature by the brain's outputs and pays for the tick.
+func (c *Creature) Act(output []float64, motors Motors, terrainSpeedFactor, terrainEnergyFactor, maxAge, stressFactor float64) {
 	// Movement
-	// Output is [-1, 1]. Speed is max speed.
-	// Apply terrain penalty
+	// Outputs are [-1, 1]. Apply terrain penalty to max speed
 	currentMaxSpeed := c.Speed * terrainSpeedFactor
 
-	dx := output[0] * currentMaxSpeed
-	dy := output[1] * currentMaxSpeed
-
-	c.X += dx
-	c.Y += dy
-
 	// Energy Calculation (Thermodynamics)
 	// 1. Basal Metabolic Rate (Living cost)
-	// 2. Movement Cost (Work = Force * Distance). F = ma. Heavier creatures spend more energy moving.
+	// 2. Movement Cost (Work = Force * Distance). F = ma. Heavier creatures spend more energy moving and accelerating.
 	// 3. Terrain Resistance (Mud/Water makes it harder)
 	// 4. Aging Cost (Gradient Aging). As creatures age, they become less efficient.
-	movementDist := math.Sqrt(dx*dx + dy*dy)
-	movementCost := movementDist * c.Mass * 0.1 * terrainEnergyFactor
+	movementCost := motors.move(c, output, currentMaxSpeed, terrainEnergyFactor)
 
 	// Calculate aging factor: 1 + (Age/MaxAge)^2
 	// This means young creatures pay ~1x BMR, but old ones pay significantly more.
@@ -122,15 +146,41 @@ func (c *Creature) Update(foodX, foodY, enemyX, enemyY, targetIsCarnivore, terra
 	c.Age++
 }
 
-func (c *Creature) ReproduceAsexual(mutationRate, mutationStrength, brainCostPerNeuron float64) *Creature {
+// brainLayers returns hidden layer sizes for a brain with n layers, and the
+// upkeep of the layers after the first, which CalculateStats doesn't charge.
+func brainLayers(g Genome, n int, brainCostPerNeuron float64) (layers []int, extraBMR float64) {
+	layers = g.LayerSizes(max(n, 1))
+	for k := 1; k < len(layers); k++ {
+		extraBMR += float64(layers[k]) * brainCostPerNeuron
+	}
+	return layers, extraBMR
+}
+
+// Learn lets a plastic brain adapt to the energy change since energyBefore.
+func (c *Creature) Learn(energyBefore float64) {
+	if p, ok := c.Brain.(brain.Plastic); ok {
+		p.Learn((c.Energy - energyBefore) / c.MaxEnergy)
+	}
+}
+
+// ConsolidateLearning makes what the brain learned heritable.
+func (c *Creature) ConsolidateLearning() {
+	if p, ok := c.Brain.(brain.Plastic); ok {
+		p.Consolidate()
+	}
+}
+
+func (c *Creature) ReproduceAsexual(mutation Mutation, brainCostPerNeuron, visionCost float64) *Creature {
 	// Mutate Genome
-	childGenome := c.Genome.Mutate(mutationRate, mutationStrength)
+	mutationRate, mutationStrength := mutation.For(c.Genome)
+	childGenome := c.Genome.Mutate(mutationRate, mutationStrength).MutateMarkers(mutation.MarkerRate)
 
 	// Calculate new Phenotype (may have different hidden size)
-	mass, speed, view, bmr, maxEnergy, reproThresh, isCarn, hiddenSize := childGenome.CalculateStats(brainCostPerNeuron)
+	mass, speed, view, bmr, maxEnergy, reproThresh, isCarn, _ := childGenome.CalculateStats(brainCostPerNeuron, visionCost)
+	layers, extraBMR := brainLayers(childGenome, c.Brain.Layers(), brainCostPerNeuron)
 
 	// Clone brain, adapting to child's hidden size
-	childBrain := c.Brain.CloneWithResize(hiddenSize)
+	childBrain := c.Brain.Offspring(nil, layers)
 	childBrain.Mutate(mutationRate, mutationStrength)
 
 	child := &Creature{
@@ -139,13 +189,16 @@ func (c *Creature) ReproduceAsexual(mutationRate, mutationStrength, brainCostPer
 		Generation: c.Generation + 1,
 		X:          c.X,
 		Y:          c.Y,
+		Heading:    c.Heading,
+		VX:         c.VX,
+		VY:         c.VY,
 		Energy:     c.Energy / 2, // Parent gives half energy
 
 		Size:                  childGenome.ExpressedSize(),
 		Mass:                  mass,
 		Speed:                 speed,
 		ViewRadius:            view,
-		BMR:                   bmr,
+		BMR:                   bmr + extraBMR,
 		MaxEnergy:             maxEnergy,
 		ReproductionThreshold: reproThresh,
 		IsCarnivore:           isCarn,
@@ -159,15 +212,17 @@ func (c *Creature) ReproduceAsexual(mutationRate, mutationStrength, brainCostPer
 	return child
 }
 
-func (c *Creature) ReproduceSexual(mate *Creature, mutationRate, mutationStrength, inbreedingThreshold, inbreedingPenalty, brainCostPerNeuron float64) *Creature {
-	// Crossover genomes + mutate
-	childGenome := c.Genome.Crossover(mate.Genome)
-	childGenome = childGenome.Mutate(mutationRate, mutationStrength)
+func (c *Creature) ReproduceSexual(mate *Creature, meiosis Meiosis, mutation Mutation, inbreedingThreshold, inbreedingPenalty, brainCostPerNeuron, visionCost float64) *Creature {
+	// Crossover genomes + mutate, at the rates the child inherited
+	childGenome := c.Genome.Crossover(mate.Genome, meiosis)
+	mutationRate, mutationStrength := mutation.For(childGenome)
+	childGenome = childGenome.Mutate(mutationRate, mutationStrength).MutateMarkers(mutation.MarkerRate)
 
-	mass, speed, view, bmr, maxEnergy, reproThresh, isCarn, hiddenSize := childGenome.CalculateStats(brainCostPerNeuron)
+	mass, speed, view, bmr, maxEnergy, reproThresh, isCarn, _ := childGenome.CalculateStats(brainCostPerNeuron, visionCost)
+	layers, extraBMR := brainLayers(childGenome, c.Brain.Layers(), brainCostPerNeuron)
 
 	// Crossover brains with child's hidden size, then mutate
-	childBrain := c.Brain.CrossoverWithResize(mate.Brain, hiddenSize)
+	childBrain := c.Brain.Offspring(mate.Brain, layers)
 	childBrain.Mutate(mutationRate, mutationStrength)
 
 	// Each parent gives 1/3 of energy
@@ -194,12 +249,15 @@ func (c *Creature) ReproduceSexual(mate *Creature, mutationRate, mutationStrengt
 		Generation:            gen + 1,
 		X:                     (c.X + mate.X) / 2,
 		Y:                     (c.Y + mate.Y) / 2,
+		Heading:               c.Heading,
+		VX:                    c.VX,
+		VY:                    c.VY,
 		Energy:                childEnergy,
 		Size:                  childGenome.ExpressedSize(),
 		Mass:                  mass,
 		Speed:                 speed,
 		ViewRadius:            view,
-		BMR:                   bmr,
+		BMR:                   bmr + extraBMR,
 		MaxEnergy:             maxEnergy,
 		ReproductionThreshold: reproThresh,
 		IsCarnivore:           isCarn,
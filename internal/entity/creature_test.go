@@ -21,17 +21,19 @@ func TestCreature_ReproduceSexual(t *testing.T) {
 		MaxEnergy:             1000.0,
 		ReproductionThreshold: 500.0,
 		IsCarnivore:           false,
-		Genome: Genome{
-			SizeAllele1: 1.0, SizeAllele2: 1.0,
-			SpeedAllele1: 1.0, SpeedAllele2: 1.0,
-			SenseAllele1: 100.0, SenseAllele2: 100.0,
-			DietAllele1: 0.2, DietAllele2: 0.2,
-			MetabolismAllele1: 1.0, MetabolismAllele2: 1.0,
-			FertilityAllele1: 0.5, FertilityAllele2: 0.5,
-			ConstitutionAllele1: 1.0, ConstitutionAllele2: 1.0,
-			HiddenAllele1: 6.0, HiddenAllele2: 6.0,
-			ColorR: 0.0, ColorG: 1.0, ColorB: 0.0,
-		},
+		Genome: genome(map[int][2]float64{
+			LocusSize:         {1.0, 1.0},
+			LocusSpeed:        {1.0, 1.0},
+			LocusSense:        {100.0, 100.0},
+			LocusDiet:         {0.2, 0.2},
+			LocusMetabolism:   {1.0, 1.0},
+			LocusFertility:    {0.5, 0.5},
+			LocusConstitution: {1.0, 1.0},
+			LocusHidden:       {6.0, 6.0},
+			LocusColorR:       {0.0, 0.0},
+			LocusColorG:       {1.0, 1.0},
+			LocusColorB:       {0.0, 0.0},
+		}),
 		Brain: brain.NewNetwork(11, 6, 2),
 	}
 	p2 := &Creature{
@@ -47,24 +49,26 @@ func TestCreature_ReproduceSexual(t *testing.T) {
 		MaxEnergy:             2000.0,
 		ReproductionThreshold: 1000.0,
 		IsCarnivore:           false,
-		Genome: Genome{
-			SizeAllele1: 1.5, SizeAllele2: 1.5,
-			SpeedAllele1: 0.8, SpeedAllele2: 0.8,
-			SenseAllele1: 120.0, SenseAllele2: 120.0,
-			DietAllele1: 0.3, DietAllele2: 0.3,
-			MetabolismAllele1: 1.0, MetabolismAllele2: 1.0,
-			FertilityAllele1: 0.5, FertilityAllele2: 0.5,
-			ConstitutionAllele1: 1.0, ConstitutionAllele2: 1.0,
-			HiddenAllele1: 6.0, HiddenAllele2: 6.0,
-			ColorR: 0.0, ColorG: 0.5, ColorB: 0.5,
-		},
+		Genome: genome(map[int][2]float64{
+			LocusSize:         {1.5, 1.5},
+			LocusSpeed:        {0.8, 0.8},
+			LocusSense:        {120.0, 120.0},
+			LocusDiet:         {0.3, 0.3},
+			LocusMetabolism:   {1.0, 1.0},
+			LocusFertility:    {0.5, 0.5},
+			LocusConstitution: {1.0, 1.0},
+			LocusHidden:       {6.0, 6.0},
+			LocusColorR:       {0.0, 0.0},
+			LocusColorG:       {0.5, 0.5},
+			LocusColorB:       {0.5, 0.5},
+		}),
 		Brain: brain.NewNetwork(11, 6, 2),
 	}
 
 	p1EnergyBefore := p1.Energy
 	p2EnergyBefore := p2.Energy
 
-	child := p1.ReproduceSexual(p2, 0.1, 0.2, 0.15, 0.2, 0.005)
+	child := p1.ReproduceSexual(p2, Meiosis{}, Mutation{Rate: 0.1, Strength: 0.2}, 0.15, 0.2, 0.005, 0)
 
 	// Each parent loses 1/3 of their energy
 	expectedP1Loss := p1EnergyBefore / 3
@@ -1,40 +1,50 @@
 package entity
 
 import (
+	"encoding/json"
 	"math"
 	"testing"
 )
 
+// genome builds a genome from alleles per locus, leaving the rest at zero.
+func genome(alleles map[int][2]float64) Genome {
+	var g Genome
+	for locus, a := range alleles {
+		g.Alleles[locus] = a
+	}
+	return g
+}
+
 func TestGenome_CalculateStats(t *testing.T) {
 	// 1. Small herbivore
-	g1 := Genome{
-		SizeAllele1: 0.5, SizeAllele2: 0.5,
-		SpeedAllele1: 1.0, SpeedAllele2: 1.0,
-		SenseAllele1: 100.0, SenseAllele2: 100.0,
-		DietAllele1: 0.2, DietAllele2: 0.2,
-		MetabolismAllele1: 1.0, MetabolismAllele2: 1.0,
-		FertilityAllele1: 0.7, FertilityAllele2: 0.7,
-		ConstitutionAllele1: 1.0, ConstitutionAllele2: 1.0,
-		HiddenAllele1: 6.0, HiddenAllele2: 6.0,
-	}
-	mass1, speed1, _, bmr1, _, _, isCarn1, _ := g1.CalculateStats(0.005)
+	g1 := genome(map[int][2]float64{
+		LocusSize:         {0.5, 0.5},
+		LocusSpeed:        {1.0, 1.0},
+		LocusSense:        {100.0, 100.0},
+		LocusDiet:         {0.2, 0.2},
+		LocusMetabolism:   {1.0, 1.0},
+		LocusFertility:    {0.7, 0.7},
+		LocusConstitution: {1.0, 1.0},
+		LocusHidden:       {6.0, 6.0},
+	})
+	mass1, speed1, _, bmr1, _, _, isCarn1, _ := g1.CalculateStats(0.005, 0)
 
 	if isCarn1 {
 		t.Errorf("Expected herbivore, got carnivore")
 	}
 
 	// 2. Large carnivore
-	g2 := Genome{
-		SizeAllele1: 2.0, SizeAllele2: 2.0,
-		SpeedAllele1: 1.0, SpeedAllele2: 1.0,
-		SenseAllele1: 100.0, SenseAllele2: 100.0,
-		DietAllele1: 0.8, DietAllele2: 0.8,
-		MetabolismAllele1: 1.0, MetabolismAllele2: 1.0,
-		FertilityAllele1: 0.7, FertilityAllele2: 0.7,
-		ConstitutionAllele1: 1.0, ConstitutionAllele2: 1.0,
-		HiddenAllele1: 6.0, HiddenAllele2: 6.0,
-	}
-	mass2, speed2, _, bmr2, _, _, isCarn2, _ := g2.CalculateStats(0.005)
+	g2 := genome(map[int][2]float64{
+		LocusSize:         {2.0, 2.0},
+		LocusSpeed:        {1.0, 1.0},
+		LocusSense:        {100.0, 100.0},
+		LocusDiet:         {0.8, 0.8},
+		LocusMetabolism:   {1.0, 1.0},
+		LocusFertility:    {0.7, 0.7},
+		LocusConstitution: {1.0, 1.0},
+		LocusHidden:       {6.0, 6.0},
+	})
+	mass2, speed2, _, bmr2, _, _, isCarn2, _ := g2.CalculateStats(0.005, 0)
 
 	if !isCarn2 {
 		t.Errorf("Expected carnivore, got herbivore")
@@ -54,24 +64,23 @@ func TestGenome_CalculateStats(t *testing.T) {
 }
 
 func TestGenome_Epistasis(t *testing.T) {
-	gBase := Genome{
-		SizeAllele1: 1.0, SizeAllele2: 1.0,
-		SpeedAllele1: 1.0, SpeedAllele2: 1.0,
-		SenseAllele1: 100.0, SenseAllele2: 100.0,
-		DietAllele1: 0.5, DietAllele2: 0.5,
-		MetabolismAllele1: 1.0, MetabolismAllele2: 1.0,
-		FertilityAllele1: 0.7, FertilityAllele2: 0.7,
-		ConstitutionAllele1: 1.0, ConstitutionAllele2: 1.0,
-		HiddenAllele1: 6.0, HiddenAllele2: 6.0,
-	}
+	gBase := genome(map[int][2]float64{
+		LocusSize:         {1.0, 1.0},
+		LocusSpeed:        {1.0, 1.0},
+		LocusSense:        {100.0, 100.0},
+		LocusDiet:         {0.5, 0.5},
+		LocusMetabolism:   {1.0, 1.0},
+		LocusFertility:    {0.7, 0.7},
+		LocusConstitution: {1.0, 1.0},
+		LocusHidden:       {6.0, 6.0},
+	})
 
-	massBase, _, _, _, maxEnergyBase, _, _, _ := gBase.CalculateStats(0.005)
+	massBase, _, _, _, maxEnergyBase, _, _, _ := gBase.CalculateStats(0.005, 0)
 
 	gDense := gBase
-	gDense.ConstitutionAllele1 = 1.5
-	gDense.ConstitutionAllele2 = 1.5
+	gDense.Alleles[LocusConstitution] = [2]float64{1.5, 1.5}
 
-	massDense, _, _, _, maxEnergyDense, _, _, _ := gDense.CalculateStats(0.005)
+	massDense, _, _, _, maxEnergyDense, _, _, _ := gDense.CalculateStats(0.005, 0)
 
 	if massDense <= massBase {
 		t.Errorf("High constitution should increase mass")
@@ -82,11 +91,10 @@ func TestGenome_Epistasis(t *testing.T) {
 
 	// Test that Metabolism affects Speed and BMR
 	gFastMeta := gBase
-	gFastMeta.MetabolismAllele1 = 1.5
-	gFastMeta.MetabolismAllele2 = 1.5
+	gFastMeta.Alleles[LocusMetabolism] = [2]float64{1.5, 1.5}
 
-	_, speedFast, _, bmrFast, _, _, _, _ := gFastMeta.CalculateStats(0.005)
-	_, speedBase, _, bmrBase, _, _, _, _ := gBase.CalculateStats(0.005)
+	_, speedFast, _, bmrFast, _, _, _, _ := gFastMeta.CalculateStats(0.005, 0)
+	_, speedBase, _, bmrBase, _, _, _, _ := gBase.CalculateStats(0.005, 0)
 
 	if speedFast <= speedBase {
 		t.Errorf("High metabolism should increase speed (assuming constant mass)")
@@ -105,10 +113,10 @@ func TestGenome_Mutate(t *testing.T) {
 	for i := 0; i < 100; i++ {
 		mutated = mutated.Mutate(0.5, 0.1)
 
-		if math.Abs(mutated.SizeAllele1-g.SizeAllele1) > 0.001 ||
-			math.Abs(mutated.SpeedAllele1-g.SpeedAllele1) > 0.001 ||
-			math.Abs(mutated.DietAllele1-g.DietAllele1) > 0.001 ||
-			math.Abs(mutated.MetabolismAllele1-g.MetabolismAllele1) > 0.001 {
+		if math.Abs(mutated.Alleles[LocusSize][0]-g.Alleles[LocusSize][0]) > 0.001 ||
+			math.Abs(mutated.Alleles[LocusSpeed][0]-g.Alleles[LocusSpeed][0]) > 0.001 ||
+			math.Abs(mutated.Alleles[LocusDiet][0]-g.Alleles[LocusDiet][0]) > 0.001 ||
+			math.Abs(mutated.Alleles[LocusMetabolism][0]-g.Alleles[LocusMetabolism][0]) > 0.001 {
 			changed = true
 			break
 		}
@@ -119,49 +127,73 @@ func TestGenome_Mutate(t *testing.T) {
 	}
 }
 
+func TestGenome_MutateStepsHiddenByOneNeuron(t *testing.T) {
+	g := genome(map[int][2]float64{LocusHidden: {7.5, 7.5}, LocusLayer2: {7.5, 7.5}})
+
+	// Brain size alleles step by about one neuron at any strength
+	for _, strength := range []float64{0.01, 1} {
+		for _, locus := range []int{LocusHidden, LocusLayer2} {
+			var sum float64
+			const n = 4000
+			for i := 0; i < n; i++ {
+				d := g.Mutate(1, strength).Alleles[locus][0] - 7.5
+				sum += d * d
+			}
+			if sd := math.Sqrt(sum / n); sd < 0.9 || sd > 1.1 {
+				t.Errorf("%s at strength %g steps by %.2f, want about 1", Loci[locus].Name, strength, sd)
+			}
+		}
+	}
+}
+
 func TestGenome_Crossover(t *testing.T) {
 	// Two maximally different genomes (homozygous for distinct values)
-	g1 := Genome{
-		SizeAllele1: 0.5, SizeAllele2: 0.5,
-		SpeedAllele1: 0.3, SpeedAllele2: 0.3,
-		SenseAllele1: 50.0, SenseAllele2: 50.0,
-		DietAllele1: 0.1, DietAllele2: 0.1,
-		MetabolismAllele1: 0.5, MetabolismAllele2: 0.5,
-		FertilityAllele1: 0.5, FertilityAllele2: 0.5,
-		ConstitutionAllele1: 0.5, ConstitutionAllele2: 0.5,
-		HiddenAllele1: 4.0, HiddenAllele2: 4.0,
-		ColorR: 0.0, ColorG: 0.0, ColorB: 0.0,
-	}
-	g2 := Genome{
-		SizeAllele1: 3.5, SizeAllele2: 3.5,
-		SpeedAllele1: 2.8, SpeedAllele2: 2.8,
-		SenseAllele1: 450.0, SenseAllele2: 450.0,
-		DietAllele1: 0.9, DietAllele2: 0.9,
-		MetabolismAllele1: 2.0, MetabolismAllele2: 2.0,
-		FertilityAllele1: 0.9, FertilityAllele2: 0.9,
-		ConstitutionAllele1: 1.5, ConstitutionAllele2: 1.5,
-		HiddenAllele1: 10.0, HiddenAllele2: 10.0,
-		ColorR: 1.0, ColorG: 1.0, ColorB: 1.0,
-	}
+	g1 := genome(map[int][2]float64{
+		LocusSize:         {0.5, 0.5},
+		LocusSpeed:        {0.3, 0.3},
+		LocusSense:        {50.0, 50.0},
+		LocusDiet:         {0.1, 0.1},
+		LocusMetabolism:   {0.5, 0.5},
+		LocusFertility:    {0.5, 0.5},
+		LocusConstitution: {0.5, 0.5},
+		LocusHidden:       {4.0, 4.0},
+		LocusColorR:       {0.0, 0.0},
+		LocusColorG:       {0.0, 0.0},
+		LocusColorB:       {0.0, 0.0},
+	})
+	g2 := genome(map[int][2]float64{
+		LocusSize:         {3.5, 3.5},
+		LocusSpeed:        {2.8, 2.8},
+		LocusSense:        {450.0, 450.0},
+		LocusDiet:         {0.9, 0.9},
+		LocusMetabolism:   {2.0, 2.0},
+		LocusFertility:    {0.9, 0.9},
+		LocusConstitution: {1.5, 1.5},
+		LocusHidden:       {10.0, 10.0},
+		LocusColorR:       {1.0, 1.0},
+		LocusColorG:       {1.0, 1.0},
+		LocusColorB:       {1.0, 1.0},
+	})
 
 	// Run crossover many times to verify mixing
 	sawG1Size := false
 	sawG2Size := false
 	for i := 0; i < 100; i++ {
-		child := g1.Crossover(g2)
+		child := g1.Crossover(g2, Meiosis{})
 
 		// Child allele1 comes from g1, allele2 from g2 (both homozygous)
-		if child.SizeAllele1 != g1.SizeAllele1 && child.SizeAllele1 != g1.SizeAllele2 {
-			t.Fatalf("Child SizeAllele1 %f is not from parent1", child.SizeAllele1)
+		size, size1, size2 := child.Alleles[LocusSize], g1.Alleles[LocusSize], g2.Alleles[LocusSize]
+		if size[0] != size1[0] && size[0] != size1[1] {
+			t.Fatalf("Child size allele 1 %f is not from parent1", size[0])
 		}
-		if child.SizeAllele2 != g2.SizeAllele1 && child.SizeAllele2 != g2.SizeAllele2 {
-			t.Fatalf("Child SizeAllele2 %f is not from parent2", child.SizeAllele2)
+		if size[1] != size2[0] && size[1] != size2[1] {
+			t.Fatalf("Child size allele 2 %f is not from parent2", size[1])
 		}
 
-		if child.SizeAllele1 == g1.SizeAllele1 {
+		if size[0] == size1[0] {
 			sawG1Size = true
 		}
-		if child.SizeAllele2 == g2.SizeAllele1 {
+		if size[1] == size2[0] {
 			sawG2Size = true
 		}
 	}
@@ -173,16 +205,16 @@ func TestGenome_Crossover(t *testing.T) {
 
 func TestGenome_Diploid_Dominance(t *testing.T) {
 	// Size uses dominant (max) expression
-	g := Genome{
-		SizeAllele1: 1.0, SizeAllele2: 2.0,
-		SpeedAllele1: 0.5, SpeedAllele2: 1.5,
-		SenseAllele1: 100.0, SenseAllele2: 200.0,
-		DietAllele1: 0.3, DietAllele2: 0.7,
-		MetabolismAllele1: 1.0, MetabolismAllele2: 1.0,
-		FertilityAllele1: 0.7, FertilityAllele2: 0.7,
-		ConstitutionAllele1: 1.0, ConstitutionAllele2: 1.0,
-		HiddenAllele1: 6.0, HiddenAllele2: 6.0,
-	}
+	g := genome(map[int][2]float64{
+		LocusSize:         {1.0, 2.0},
+		LocusSpeed:        {0.5, 1.5},
+		LocusSense:        {100.0, 200.0},
+		LocusDiet:         {0.3, 0.7},
+		LocusMetabolism:   {1.0, 1.0},
+		LocusFertility:    {0.7, 0.7},
+		LocusConstitution: {1.0, 1.0},
+		LocusHidden:       {6.0, 6.0},
+	})
 
 	// Dominant traits: max
 	if g.ExpressedSize() != 2.0 {
@@ -202,3 +234,118 @@ func TestGenome_Diploid_Dominance(t *testing.T) {
 		t.Errorf("Diet should be dominant (max): got %f, want 0.7", g.ExpressedDiet())
 	}
 }
+
+func TestSetDominance_Modes(t *testing.T) {
+	saved := Loci
+	t.Cleanup(func() { Loci = saved })
+
+	g := genome(map[int][2]float64{LocusDiet: {0.2, 0.6}})
+	cases := []struct {
+		spec string
+		want float64
+	}{
+		{"diet=complete", 0.6},
+		{"diet=recessive", 0.2},
+		{"diet=additive", 0.4},
+		{"diet=incomplete:0.25", 0.3},
+		{"diet=overdominant:0.5", 0.8},
+		{"diet=overdominant:2", 1}, // Clamped to the locus range
+	}
+	for _, c := range cases {
+		if err := SetDominance([]string{c.spec}); err != nil {
+			t.Fatalf("%s: %v", c.spec, err)
+		}
+		if got := g.ExpressedDiet(); math.Abs(got-c.want) > 1e-9 {
+			t.Errorf("%s: expressed %f, want %f", c.spec, got, c.want)
+		}
+	}
+
+	// A homozygote has no difference to exceed
+	homo := genome(map[int][2]float64{LocusDiet: {0.3, 0.3}})
+	if homo.ExpressedDiet() != 0.3 {
+		t.Errorf("homozygote expressed %f under overdominance", homo.ExpressedDiet())
+	}
+
+	Loci = saved
+	for _, bad := range []string{"diet", "wings=additive", "diet=codominant", "color_r=recessive", "diet=additive:0.5", "diet=incomplete:1.5"} {
+		if err := SetDominance([]string{"size=recessive", bad}); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+		if Loci[LocusSize].Dominance != saved[LocusSize].Dominance {
+			t.Fatalf("%q applied the valid specs before failing", bad)
+		}
+	}
+}
+
+func TestGenome_VisionCostScalesWithFOV(t *testing.T) {
+	narrow := NewRandomGenome()
+	narrow.Alleles[LocusFOV] = [2]float64{1.0, 1.0}
+	wide := narrow
+	wide.Alleles[LocusFOV] = [2]float64{3.0, 3.0}
+
+	_, _, _, bmrNarrow, _, _, _, _ := narrow.CalculateStats(0.005, 0.01)
+	_, _, _, bmrWide, _, _, _, _ := wide.CalculateStats(0.005, 0.01)
+	if d := bmrWide - bmrNarrow; math.Abs(d-0.02) > 1e-9 {
+		t.Errorf("2 more radians of view cost %f BMR, want 0.02", d)
+	}
+
+	_, _, _, bmrFree, _, _, _, _ := wide.CalculateStats(0.005, 0)
+	_, _, _, bmrFreeNarrow, _, _, _, _ := narrow.CalculateStats(0.005, 0)
+	if bmrFree != bmrFreeNarrow {
+		t.Errorf("field of view cost BMR without vision rays")
+	}
+}
+
+func TestLoci_AreConsistent(t *testing.T) {
+	seen := map[string]bool{}
+	for i, l := range Loci {
+		if l.Name == "" || seen[l.Name] {
+			t.Errorf("locus %d has a missing or repeated name %q", i, l.Name)
+		}
+		seen[l.Name] = true
+		if l.Init == nil || (l.MutationScale <= 0 && l.MutationStep <= 0) || l.Min >= l.Max {
+			t.Errorf("locus %s is incomplete: %+v", l.Name, l)
+		}
+	}
+
+	for n := 0; n < 100; n++ {
+		g := NewRandomGenome().Mutate(1, 1)
+		for i, l := range Loci {
+			for _, a := range g.Alleles[i] {
+				if a < l.Min || a > l.Max {
+					t.Fatalf("locus %s allele %f outside [%f, %f]", l.Name, a, l.Min, l.Max)
+				}
+			}
+			if l.Haploid && g.Alleles[i][0] != g.Alleles[i][1] {
+				t.Fatalf("haploid locus %s has two different copies", l.Name)
+			}
+		}
+	}
+}
+
+func TestGenome_JSONByLocusName(t *testing.T) {
+	g := NewRandomGenome()
+	g.Sensors = "food,energy"
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back Genome
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if back != g {
+		t.Errorf("round trip changed the genome:\n%+v\n%+v", g, back)
+	}
+
+	// Missing loci take their defaults, unknown ones are ignored
+	if err := json.Unmarshal([]byte(`{"Alleles":{"size":[2,3],"wings":[1,1]}}`), &back); err != nil {
+		t.Fatal(err)
+	}
+	if back.Alleles[LocusSize] != [2]float64{2, 3} {
+		t.Errorf("size alleles %v, want [2 3]", back.Alleles[LocusSize])
+	}
+	if back.ExpressedSense() != Loci[LocusSense].Default || back.ExpressedFOV() != DefaultFOV {
+		t.Errorf("missing loci didn't default: sense %f, fov %f", back.ExpressedSense(), back.ExpressedFOV())
+	}
+}
@@ -0,0 +1,202 @@
+package entity
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
+// Dominance is how a diploid locus combines its two alleles into a trait
+// value. Every mode places the trait relative to the smaller and larger
+// allele, so "dominant" always means the larger one wins.
+type Dominance uint8
+
+const (
+	Complete     Dominance = iota // The larger allele
+	Recessive                     // The smaller allele, as if the larger were recessive
+	Additive                      // Mean of the alleles
+	Incomplete                    // A fraction H of the way from the smaller allele to the larger
+	Overdominant                  // Past the larger allele by H times the difference: heterozygote advantage
+)
+
+var dominanceNames = []string{"complete", "recessive", "additive", "incomplete", "overdominant"}
+
+func (d Dominance) String() string {
+	if int(d) < len(dominanceNames) {
+		return dominanceNames[d]
+	}
+	return fmt.Sprintf("Dominance(%d)", d)
+}
+
+// Locus declares a gene. Adding a trait means adding a locus here and reading
+// it with Genome.Express.
+type Locus struct {
+	Name           string // Key in serialized genomes
+	Min, Max       float64
+	Init           func() float64 // Alleles of random genomes
+	Default        float64        // Alleles of genomes recorded before the locus existed
+	Haploid        bool           // A single copy, inherited from either parent
+	Marker         bool           // Neutral marker, see MutateMarkers
+	Chromosome     int            // 1-based; 0 assorts independently even with linkage
+	Position       float64        // Place on the chromosome, in [0, 1]
+	Dominance      Dominance
+	H              float64 // Dominance coefficient of Incomplete and Overdominant
+	MutationScale  float64 // Multiplies MUTATION_STRENGTH
+	MutationStep   float64 // Fixed mutation stdev instead, if set
+	DistanceWeight float64 // Weight of the trait in Genome.Distance
+}
+
+// Loci indexes, in Loci order. Genome codes store loci by position, so new
+// loci go at the end.
+const (
+	LocusSize = iota
+	LocusSpeed
+	LocusSense
+	LocusDiet
+	LocusMetabolism
+	LocusFertility
+	LocusConstitution
+	LocusHidden
+	LocusLayer2 // Hidden layers after the first; only deep brains express them
+	LocusLayer3
+	LocusLayer4
+	LocusFOV
+	LocusColorR
+	LocusColorG
+	LocusColorB
+	LocusMutationRate     // log2 of the factor on MUTATION_RATE, see Mutation
+	LocusMutationStrength // log2 of the factor on MUTATION_STRENGTH
+	LocusMarkers          // First of NumMarkers neutral markers
+	NumLoci               = LocusMarkers + NumMarkers
+)
+
+// uniform draws from [lo, hi).
+func uniform(lo, hi float64) func() float64 {
+	return func() float64 { return lo + rand.Float64()*(hi-lo) }
+}
+
+// Hidden layer alleles step by about one neuron whatever the strength. A
+// layer default of 0 makes LayerSizes follow the first layer.
+var layerLocus = Locus{Min: 3, Max: 12, Init: uniform(4, 8), Dominance: Additive, MutationStep: 1}
+
+// Loci is the gene table. With linkage, size and diet sit close together on
+// chromosome 1, so big carnivores and small herbivores breed true; colour
+// genes stay unlinked.
+var Loci = withMarkers([NumLoci]Locus{
+	LocusSize:         {Name: "size", Min: 0.4, Max: 4, Init: uniform(0.5, 1.5), Default: 1, Dominance: Complete, MutationScale: 1, DistanceWeight: 1, Chromosome: 1, Position: 0.1},
+	LocusSpeed:        {Name: "speed", Min: 0.2, Max: 3, Init: uniform(0.75, 1.25), Default: 1, Dominance: Complete, MutationScale: 1, DistanceWeight: 1, Chromosome: 1, Position: 0.7},
+	LocusSense:        {Name: "sense", Min: 30, Max: 500, Init: uniform(75, 125), Default: 100, Dominance: Additive, MutationScale: 1, DistanceWeight: 0.01, Chromosome: 2, Position: 0.2},
+	LocusDiet:         {Name: "diet", Min: 0, Max: 1, Init: uniform(0, 1), Default: 0.5, Dominance: Complete, MutationScale: 1, DistanceWeight: 1, Chromosome: 1, Position: 0.2},
+	LocusMetabolism:   {Name: "metabolism", Min: 0.5, Max: 2.5, Init: uniform(0.75, 1.25), Default: 1, Dominance: Additive, MutationScale: 1, DistanceWeight: 1, Chromosome: 3, Position: 0.1},
+	LocusFertility:    {Name: "fertility", Min: 0.3, Max: 0.95, Init: uniform(0.5, 0.9), Default: 0.7, Dominance: Additive, MutationScale: 1, DistanceWeight: 1, Chromosome: 3, Position: 0.5},
+	LocusConstitution: {Name: "constitution", Min: 0.4, Max: 2, Init: uniform(0.75, 1.25), Default: 1, Dominance: Additive, MutationScale: 1, DistanceWeight: 1, Chromosome: 3, Position: 0.9},
+	LocusHidden:       {Name: "hidden", Min: 3, Max: 12, Init: uniform(4, 8), Default: 6, Dominance: Additive, MutationStep: 1, DistanceWeight: 0.1, Chromosome: 4, Position: 0.1},
+	LocusLayer2:       placed(named(layerLocus, "layer2"), 4, 0.3),
+	LocusLayer3:       placed(named(layerLocus, "layer3"), 4, 0.5),
+	LocusLayer4:       placed(named(layerLocus, "layer4"), 4, 0.7),
+	LocusFOV:          {Name: "fov", Min: 0.3, Max: 2 * math.Pi, Init: uniform(1.5, 2.5), Default: DefaultFOV, Dominance: Additive, MutationScale: 1, Chromosome: 2, Position: 0.5},
+	LocusColorR:       {Name: "color_r", Min: 0, Max: 1, Init: uniform(0, 1), Haploid: true, MutationScale: 1},
+	LocusColorG:       {Name: "color_g", Min: 0, Max: 1, Init: uniform(0, 1), Haploid: true, MutationScale: 1},
+	LocusColorB:       {Name: "color_b", Min: 0, Max: 1, Init: uniform(0, 1), Haploid: true, MutationScale: 1},
+	// Every genome starts at the configured mutation rate and strength
+	LocusMutationRate:     {Name: "mutation_rate", Min: -mutationGeneMax, Max: mutationGeneMax, Init: uniform(0, 0), Dominance: Additive, MutationScale: 1},
+	LocusMutationStrength: {Name: "mutation_strength", Min: -mutationGeneMax, Max: mutationGeneMax, Init: uniform(0, 0), Dominance: Additive, MutationScale: 1},
+})
+
+// withMarkers fills in the marker loci, marker0 onwards. Markers of random
+// genomes start at 0, so founders share them like a common ancestor.
+func withMarkers(loci [NumLoci]Locus) [NumLoci]Locus {
+	for k := 0; k < NumMarkers; k++ {
+		loci[LocusMarkers+k] = Locus{Name: fmt.Sprintf("marker%d", k), Min: -markerMax, Max: markerMax, Init: uniform(0, 0), Haploid: true, Marker: true, MutationScale: 1}
+	}
+	return loci
+}
+
+func named(l Locus, name string) Locus {
+	l.Name = name
+	return l
+}
+
+func placed(l Locus, chromosome int, position float64) Locus {
+	l.Chromosome, l.Position = chromosome, position
+	return l
+}
+
+// LocusIndex finds a locus by name.
+func LocusIndex(name string) (int, bool) {
+	for i, l := range Loci {
+		if l.Name == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// express combines two alleles of the locus into a trait value.
+func (l *Locus) express(a [2]float64) float64 {
+	if l.Haploid {
+		return a[0]
+	}
+	lo, hi := math.Min(a[0], a[1]), math.Max(a[0], a[1])
+	switch l.Dominance {
+	case Complete:
+		return hi
+	case Recessive:
+		return lo
+	case Incomplete:
+		return lo + l.H*(hi-lo)
+	case Overdominant:
+		return l.clamp(hi + l.H*(hi-lo))
+	default:
+		return (lo + hi) / 2
+	}
+}
+
+// SetDominance overrides the dominance of loci from "locus=mode" specs, where
+// mode is a Dominance name. Incomplete and overdominant take a coefficient
+// after a colon, as in "diet=incomplete:0.7"; without one they use 0.5.
+func SetDominance(specs []string) error {
+	table := Loci
+	for _, spec := range specs {
+		name, mode, ok := strings.Cut(spec, "=")
+		if !ok {
+			return fmt.Errorf("%q is not locus=mode", spec)
+		}
+		i, ok := LocusIndex(strings.TrimSpace(name))
+		if !ok {
+			return fmt.Errorf("unknown locus %q", name)
+		}
+		if table[i].Haploid {
+			return fmt.Errorf("locus %s is haploid", table[i].Name)
+		}
+		mode, coef, hasCoef := strings.Cut(strings.TrimSpace(mode), ":")
+		d := -1
+		for k, n := range dominanceNames {
+			if n == mode {
+				d = k
+			}
+		}
+		if d < 0 {
+			return fmt.Errorf("unknown dominance %q, want one of %s", mode, strings.Join(dominanceNames, ", "))
+		}
+		h := 0.5
+		if hasCoef {
+			if Dominance(d) != Incomplete && Dominance(d) != Overdominant {
+				return fmt.Errorf("%s dominance takes no coefficient", mode)
+			}
+			var err error
+			if h, err = strconv.ParseFloat(coef, 64); err != nil || h < 0 || (Dominance(d) == Incomplete && h > 1) {
+				return fmt.Errorf("bad %s coefficient %q", mode, coef)
+			}
+		}
+		table[i].Dominance, table[i].H = Dominance(d), h
+	}
+	Loci = table
+	return nil
+}
+
+func (l *Locus) clamp(v float64) float64 {
+	return math.Max(l.Min, math.Min(l.Max, v))
+}
@@ -0,0 +1,40 @@
+package entity
+
+import "math/rand/v2"
+
+// Neutral markers are loci nothing expresses. They pass down the line of
+// first parents, which species follow too, and mutate by a unit step at a
+// fixed rate per birth, so their differences keep time like a molecular
+// clock whatever selection does to the other genes.
+const (
+	NumMarkers = 16
+	markerMax  = 1e6
+)
+
+// MutateMarkers returns a copy of g whose markers each took a random step
+// with probability rate.
+func (g Genome) MutateMarkers(rate float64) Genome {
+	for i := LocusMarkers; i < LocusMarkers+NumMarkers; i++ {
+		if rand.Float64() < rate {
+			a := Loci[i].clamp(g.Alleles[i][0] + rand.NormFloat64())
+			g.Alleles[i] = [2]float64{a, a}
+		}
+	}
+	return g
+}
+
+// MarkerDivergence estimates from the markers how many births separate a and
+// b from their common ancestor, counted down both lines. Each birth adds a
+// step with probability rate, and unit steps add their variance, so the mean
+// squared marker difference grows by rate per birth.
+func MarkerDivergence(a, b Genome, rate float64) float64 {
+	if rate <= 0 {
+		return 0
+	}
+	var sum float64
+	for i := LocusMarkers; i < LocusMarkers+NumMarkers; i++ {
+		d := a.Alleles[i][0] - b.Alleles[i][0]
+		sum += d * d
+	}
+	return sum / NumMarkers / rate
+}
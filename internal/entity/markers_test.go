@@ -0,0 +1,56 @@
+package entity
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMarkerDivergence_KeepsTime(t *testing.T) {
+	const rate, births, trials = 0.5, 40, 200
+
+	var sum float64
+	for n := 0; n < trials; n++ {
+		a, b := NewRandomGenome(), NewRandomGenome()
+		for k := 0; k < births; k++ {
+			a = a.MutateMarkers(rate)
+			b = b.MutateMarkers(rate)
+		}
+		sum += MarkerDivergence(a, b, rate)
+	}
+	if mean := sum / trials; math.Abs(mean-2*births)/(2*births) > 0.1 {
+		t.Errorf("mean estimate %.1f births, want %d", mean, 2*births)
+	}
+}
+
+func TestMarkers_AreNeutralAndFollowTheFirstParent(t *testing.T) {
+	g := NewRandomGenome()
+	for k := 0; k < 20; k++ {
+		g = g.MutateMarkers(1)
+	}
+
+	if m := g.Mutate(1, 1); m.Alleles[LocusMarkers] != g.Alleles[LocusMarkers] {
+		t.Errorf("Mutate changed a marker")
+	}
+	plain := g
+	for i := LocusMarkers; i < NumLoci; i++ {
+		plain.Alleles[i] = [2]float64{}
+	}
+	if d := g.Distance(plain); d != 0 {
+		t.Errorf("markers add %f to genetic distance", d)
+	}
+	mass, speed, view, bmr, _, _, _, _ := g.CalculateStats(0.005, 0.01)
+	plainMass, plainSpeed, plainView, plainBMR, _, _, _, _ := plain.CalculateStats(0.005, 0.01)
+	if mass != plainMass || speed != plainSpeed || view != plainView || bmr != plainBMR {
+		t.Errorf("markers change stats")
+	}
+
+	mate := NewRandomGenome()
+	for n := 0; n < 20; n++ {
+		child := g.Crossover(mate, Meiosis{})
+		for i := LocusMarkers; i < NumLoci; i++ {
+			if child.Alleles[i] != g.Alleles[i] {
+				t.Fatalf("child marker %v, want the first parent's %v", child.Alleles[i], g.Alleles[i])
+			}
+		}
+	}
+}
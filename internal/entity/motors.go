@@ -0,0 +1,93 @@
+package entity
+
+import "math"
+
+// Motor models.
+const (
+	MotorsDirect = "direct" // Outputs are a velocity vector, applied instantly
+	MotorsThrust = "thrust" // Outputs push along the heading and turn it
+)
+
+// Motors is how brain outputs move a creature.
+type Motors struct {
+	Model    string  // MotorsDirect or MotorsThrust
+	Strafe   bool    // Thrust: a third output pushes sideways
+	Drag     float64 // Thrust: fraction of velocity a unit of mass loses per tick
+	TurnRate float64 // Thrust: radians per tick at full turn
+}
+
+// KnownMotors reports whether model names a motor model.
+func KnownMotors(model string) bool {
+	return model == MotorsDirect || model == MotorsThrust
+}
+
+// Names labels the motor outputs, in order.
+func (m Motors) Names() []string {
+	if m.Model == MotorsDirect {
+		return []string{"move_x", "move_y"}
+	}
+	if m.Strafe {
+		return []string{"thrust", "turn", "strafe"}
+	}
+	return []string{"thrust", "turn"}
+}
+
+// Burst dashes distance along the heading, carrying the speed into the next
+// tick.
+func (c *Creature) Burst(distance float64) {
+	dx, dy := math.Cos(c.Heading)*distance, math.Sin(c.Heading)*distance
+	c.X += dx
+	c.Y += dy
+	c.VX += dx
+	c.VY += dy
+}
+
+// Reverse thrust and strafing are weaker than forward thrust.
+const (
+	reverseThrust    = 0.5
+	strafeThrust     = 0.5
+	accelerationCost = 0.5 // Energy per unit of mass and speed gained
+)
+
+// move updates velocity, heading and position from the motor outputs and
+// returns the energy spent. maxSpeed is the top speed on the current terrain.
+func (m Motors) move(c *Creature, output []float64, maxSpeed, terrainEnergyFactor float64) float64 {
+	if m.Model == MotorsDirect {
+		c.VX = output[0] * maxSpeed
+		c.VY = output[1] * maxSpeed
+		c.X += c.VX
+		c.Y += c.VY
+		if c.VX != 0 || c.VY != 0 {
+			c.Heading = math.Atan2(c.VY, c.VX)
+		}
+		// Work = Force * Distance; heavier creatures spend more moving
+		return math.Hypot(c.VX, c.VY) * c.Mass * 0.1 * terrainEnergyFactor
+	}
+
+	c.Heading = math.Remainder(c.Heading+output[1]*m.TurnRate, 2*math.Pi)
+
+	thrust := output[0]
+	if thrust < 0 {
+		thrust *= reverseThrust
+	}
+	var strafe float64
+	if m.Strafe {
+		strafe = output[2] * strafeThrust
+	}
+
+	// Drag falls with mass, so heavy creatures are slow to speed up and to
+	// stop. Full thrust settles at maxSpeed.
+	drag := math.Min(m.Drag/c.Mass, 1)
+	cos, sin := math.Cos(c.Heading), math.Sin(c.Heading)
+	ax := (thrust*cos - strafe*sin) * maxSpeed * drag
+	ay := (thrust*sin + strafe*cos) * maxSpeed * drag
+
+	c.VX = c.VX*(1-drag) + ax
+	c.VY = c.VY*(1-drag) + ay
+	c.X += c.VX
+	c.Y += c.VY
+
+	// Pushing against the drag plus the work of accelerating the mass
+	dist := math.Hypot(c.VX, c.VY)
+	return (dist*0.1 + math.Hypot(ax, ay)*accelerationCost) * c.Mass * terrainEnergyFactor
+}
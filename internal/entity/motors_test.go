@@ -0,0 +1,79 @@
+package entity
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMotors_ThrustSettlesAtMaxSpeed(t *testing.T) {
+	m := Motors{Model: MotorsThrust, Drag: 0.1, TurnRate: 0.2}
+	c := &Creature{Mass: 1, Speed: 2}
+	for i := 0; i < 500; i++ {
+		m.move(c, []float64{1, 0}, c.Speed, 1)
+	}
+	if v := math.Hypot(c.VX, c.VY); math.Abs(v-2) > 1e-6 {
+		t.Errorf("speed %f after full thrust, want 2", v)
+	}
+	if c.VY != 0 || c.Y != 0 {
+		t.Errorf("moved sideways without turning: vy %f, y %f", c.VY, c.Y)
+	}
+}
+
+func TestMotors_HeavyCreaturesAccelerateSlower(t *testing.T) {
+	m := Motors{Model: MotorsThrust, Drag: 0.1, TurnRate: 0.2}
+	light := &Creature{Mass: 0.5, Speed: 2}
+	heavy := &Creature{Mass: 2, Speed: 2}
+	lightCost := m.move(light, []float64{1, 0}, 2, 1)
+	heavyCost := m.move(heavy, []float64{1, 0}, 2, 1)
+	if heavy.VX >= light.VX {
+		t.Errorf("heavy creature reached %f per tick, light %f", heavy.VX, light.VX)
+	}
+
+	// Coasting costs less than accelerating
+	if coast := m.move(light, []float64{0, 0}, 2, 1); coast >= lightCost {
+		t.Errorf("coasting cost %f, accelerating %f", coast, lightCost)
+	}
+	if heavyCost <= 0 {
+		t.Errorf("accelerating cost nothing")
+	}
+}
+
+func TestMotors_TurnAndStrafe(t *testing.T) {
+	m := Motors{Model: MotorsThrust, Strafe: true, Drag: 0.1, TurnRate: 0.2}
+	if names := m.Names(); len(names) != 3 {
+		t.Fatalf("strafing motors have outputs %v", names)
+	}
+	c := &Creature{Mass: 1, Speed: 1}
+	m.move(c, []float64{0, 1, 0}, 1, 1)
+	if math.Abs(c.Heading-0.2) > 1e-9 {
+		t.Errorf("heading %f after a full turn, want 0.2", c.Heading)
+	}
+	c.Heading = 0
+	m.move(c, []float64{0, 0, 1}, 1, 1)
+	if c.VY <= 0 || math.Abs(c.VX) > 1e-9 {
+		t.Errorf("strafe moved (%f, %f), want sideways only", c.VX, c.VY)
+	}
+}
+
+func TestMotors_DirectMatchesOutputs(t *testing.T) {
+	m := Motors{Model: MotorsDirect}
+	c := &Creature{X: 10, Y: 10, Mass: 1, Speed: 2}
+	m.move(c, []float64{0.5, -1}, 2, 1)
+	if c.X != 11 || c.Y != 8 {
+		t.Errorf("moved to (%f, %f), want (11, 8)", c.X, c.Y)
+	}
+	if want := math.Atan2(-2, 1); c.Heading != want {
+		t.Errorf("heading %f, want %f", c.Heading, want)
+	}
+}
+
+func TestCreature_BurstCarriesSpeed(t *testing.T) {
+	c := &Creature{Mass: 1, Speed: 1, Heading: math.Pi / 2}
+	c.Burst(3)
+	if math.Abs(c.Y-3) > 1e-9 || math.Abs(c.X) > 1e-9 {
+		t.Errorf("burst moved to (%f, %f), want (0, 3)", c.X, c.Y)
+	}
+	if math.Abs(c.VY-3) > 1e-9 {
+		t.Errorf("velocity %f after the burst, want 3", c.VY)
+	}
+}
@@ -0,0 +1,30 @@
+package entity
+
+import "math"
+
+// mutationGeneMax bounds the mutation genes: factors of 1/32 to 32.
+const mutationGeneMax = 5
+
+// Mutation is how strongly offspring differ from their parents.
+type Mutation struct {
+	Rate, Strength float64 // Chance each gene or weight mutates, and the size of a mutation
+	MarkerRate     float64 // Chance each neutral marker mutates, see MutateMarkers
+
+	// Self-adaptive mutation: each genome scales Rate and Strength by 2 to the
+	// power of its mutation genes, so they evolve from the configured values
+	// and can move up to a factor of Range either way.
+	Adaptive bool
+	Range    float64
+}
+
+// For returns the mutation rate and strength of g's offspring.
+func (m Mutation) For(g Genome) (rate, strength float64) {
+	if !m.Adaptive {
+		return m.Rate, m.Strength
+	}
+	bound := math.Log2(math.Max(m.Range, 1))
+	factor := func(locus int) float64 {
+		return math.Exp2(math.Max(-bound, math.Min(bound, g.Express(locus))))
+	}
+	return math.Min(m.Rate*factor(LocusMutationRate), 1), m.Strength * factor(LocusMutationStrength)
+}
@@ -0,0 +1,46 @@
+package entity
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMutation_ForScalesByGenes(t *testing.T) {
+	m := Mutation{Rate: 0.1, Strength: 0.2, Range: 4}
+	g := genome(map[int][2]float64{
+		LocusMutationRate:     {1, 1},
+		LocusMutationStrength: {-3, -3},
+	})
+
+	if rate, strength := m.For(g); rate != 0.1 || strength != 0.2 {
+		t.Errorf("fixed mutation: got %f, %f, want the configured 0.1, 0.2", rate, strength)
+	}
+
+	m.Adaptive = true
+	rate, strength := m.For(g)
+	if math.Abs(rate-0.2) > 1e-9 {
+		t.Errorf("rate gene 1 gave rate %f, want double, 0.2", rate)
+	}
+	if math.Abs(strength-0.05) > 1e-9 {
+		t.Errorf("strength gene -3 gave %f, want it bounded to a quarter, 0.05", strength)
+	}
+
+	if rate, _ := m.For(NewRandomGenome()); rate != 0.1 {
+		t.Errorf("random genomes start at rate %f, want the configured 0.1", rate)
+	}
+
+	m.Rate = 0.5
+	if rate, _ := m.For(genome(map[int][2]float64{LocusMutationRate: {2, 2}})); rate != 1 {
+		t.Errorf("rate %f exceeds 1", rate)
+	}
+}
+
+func TestMutation_GenesEvolve(t *testing.T) {
+	g := NewRandomGenome()
+	for n := 0; n < 50; n++ {
+		g = g.Mutate(1, 0.2)
+	}
+	if g.Alleles[LocusMutationRate] == [2]float64{} || g.Alleles[LocusMutationStrength] == [2]float64{} {
+		t.Errorf("mutation genes didn't mutate: %v, %v", g.Alleles[LocusMutationRate], g.Alleles[LocusMutationStrength])
+	}
+}
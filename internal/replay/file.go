@@ -0,0 +1,171 @@
+package replay
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Recording file layout: magic, version byte, then a gzip stream holding a
+// length-prefixed header (opaque to this package, the server stores its map
+// response there) followed by frames of tick, time, length and data.
+var fileMagic = []byte("EVRP")
+
+const fileVersion = 1
+
+// flushInterval bounds how much of a recording is lost if the process dies.
+const flushInterval = 2 * time.Second
+
+// Recorder appends frames to a recording file. Safe for concurrent use.
+type Recorder struct {
+	mu        sync.Mutex
+	f         *os.File
+	gz        *gzip.Writer
+	w         *bufio.Writer
+	lastFlush time.Time
+}
+
+func NewRecorder(path string, header []byte) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(append(append([]byte{}, fileMagic...), fileVersion)); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	gz := gzip.NewWriter(f)
+	r := &Recorder{f: f, gz: gz, w: bufio.NewWriter(gz), lastFlush: time.Now()}
+	if err := r.writeBlock(header); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Recorder) Write(frame Frame) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var head [16]byte
+	binary.LittleEndian.PutUint64(head[0:], uint64(frame.Tick))
+	binary.LittleEndian.PutUint64(head[8:], uint64(frame.Time))
+	if _, err := r.w.Write(head[:]); err != nil {
+		return err
+	}
+	if err := r.writeBlock(frame.Data); err != nil {
+		return err
+	}
+
+	if time.Since(r.lastFlush) >= flushInterval {
+		r.lastFlush = time.Now()
+		if err := r.w.Flush(); err != nil {
+			return err
+		}
+		return r.gz.Flush()
+	}
+	return nil
+}
+
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.w.Flush(); err != nil {
+		r.f.Close()
+		return err
+	}
+	if err := r.gz.Close(); err != nil {
+		r.f.Close()
+		return err
+	}
+	return r.f.Close()
+}
+
+func (r *Recorder) writeBlock(data []byte) error {
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(len(data)))
+	if _, err := r.w.Write(size[:]); err != nil {
+		return err
+	}
+	_, err := r.w.Write(data)
+	return err
+}
+
+// Recording is a recorded frame stream loaded into memory.
+type Recording struct {
+	Header []byte
+	Frames []Frame
+}
+
+// Load reads a recording file. A recording cut short, e.g. because the
+// simulation was killed, loads up to its last complete frame.
+func Load(path string) (*Recording, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	prefix := make([]byte, len(fileMagic)+1)
+	if _, err := io.ReadFull(f, prefix); err != nil || string(prefix[:len(fileMagic)]) != string(fileMagic) {
+		return nil, fmt.Errorf("%s is not a recording", path)
+	}
+	if v := prefix[len(fileMagic)]; v != fileVersion {
+		return nil, fmt.Errorf("unsupported recording version %d", v)
+	}
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(gz)
+
+	rec := &Recording{}
+	if rec.Header, err = readBlock(r); err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+
+	for {
+		var head [16]byte
+		if _, err := io.ReadFull(r, head[:]); err != nil {
+			if truncated(err) {
+				return rec, nil
+			}
+			return nil, err
+		}
+		data, err := readBlock(r)
+		if err != nil {
+			if truncated(err) {
+				return rec, nil
+			}
+			return nil, err
+		}
+		rec.Frames = append(rec.Frames, Frame{
+			Tick: int64(binary.LittleEndian.Uint64(head[0:])),
+			Time: int64(binary.LittleEndian.Uint64(head[8:])),
+			Data: data,
+		})
+	}
+}
+
+func readBlock(r io.Reader) ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+	data := make([]byte, binary.LittleEndian.Uint32(size[:]))
+	_, err := io.ReadFull(r, data)
+	return data, err
+}
+
+func truncated(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
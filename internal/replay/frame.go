@@ -0,0 +1,30 @@
+package replay
+
+import "sort"
+
+// Frame is one encoded /ws world frame stamped with the simulation tick and
+// wall-clock time (Unix milliseconds) it was captured at.
+type Frame struct {
+	Tick int64
+	Time int64
+	Data []byte
+}
+
+// Find returns the index of the last frame captured at or before time t,
+// or 0 if t is before the first frame. Frames must be ordered by Time.
+func Find(frames []Frame, t int64) int {
+	i := sort.Search(len(frames), func(i int) bool { return frames[i].Time > t })
+	if i == 0 {
+		return 0
+	}
+	return i - 1
+}
+
+// FindTick is like Find, but searches by simulation tick.
+func FindTick(frames []Frame, tick int64) int {
+	i := sort.Search(len(frames), func(i int) bool { return frames[i].Tick > tick })
+	if i == 0 {
+		return 0
+	}
+	return i - 1
+}
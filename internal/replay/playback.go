@@ -0,0 +1,65 @@
+package replay
+
+import "time"
+
+// Playback is a viewer's position in a timeline of frames. While Live, the
+// viewer follows the newest state; otherwise Position (Unix milliseconds)
+// advances at Speed times real time unless Paused.
+type Playback struct {
+	Live     bool
+	Paused   bool
+	Speed    float64
+	Position float64
+}
+
+func NewPlayback(live bool) *Playback {
+	return &Playback{Live: live, Speed: 1}
+}
+
+// Advance moves the position forward by elapsed real time.
+func (p *Playback) Advance(elapsed time.Duration) {
+	if p.Live || p.Paused {
+		return
+	}
+	p.Position += float64(elapsed.Milliseconds()) * p.Speed
+}
+
+// Rewind leaves live mode and moves the position back from the newest frame.
+func (p *Playback) Rewind(frames []Frame, back time.Duration) {
+	if len(frames) == 0 {
+		return
+	}
+	p.Live = false
+	p.Position = float64(frames[len(frames)-1].Time - back.Milliseconds())
+	p.clamp(frames)
+}
+
+// Seek leaves live mode and jumps to the frame at the given tick.
+func (p *Playback) Seek(frames []Frame, tick int64) {
+	if len(frames) == 0 {
+		return
+	}
+	p.Live = false
+	p.Position = float64(frames[FindTick(frames, tick)].Time)
+}
+
+// Frame returns the frame at the current position. ok is false in live mode
+// or when the position has run past the newest frame.
+func (p *Playback) Frame(frames []Frame) (f Frame, ok bool) {
+	if p.Live || len(frames) == 0 {
+		return Frame{}, false
+	}
+	p.clamp(frames)
+	if p.Position > float64(frames[len(frames)-1].Time) {
+		return frames[len(frames)-1], false
+	}
+	return frames[Find(frames, int64(p.Position))], true
+}
+
+// clamp keeps the position inside the buffered range, e.g. after old frames
+// were evicted while watching.
+func (p *Playback) clamp(frames []Frame) {
+	if oldest := float64(frames[0].Time); p.Position < oldest {
+		p.Position = oldest
+	}
+}
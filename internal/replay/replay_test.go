@@ -0,0 +1,95 @@
+package replay
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRing_KeepsNewestFrames(t *testing.T) {
+	r := NewRing(3)
+	for i := int64(1); i <= 5; i++ {
+		r.Push(Frame{Tick: i, Time: i * 100})
+	}
+
+	frames := r.Frames()
+	if len(frames) != 3 {
+		t.Fatalf("Expected 3 frames, got %d", len(frames))
+	}
+	for i, f := range frames {
+		if f.Tick != int64(i+3) {
+			t.Errorf("Frame %d: expected tick %d, got %d", i, i+3, f.Tick)
+		}
+	}
+}
+
+func TestPlayback_RewindAndCatchUp(t *testing.T) {
+	var frames []Frame
+	for i := int64(0); i < 10; i++ {
+		frames = append(frames, Frame{Tick: i * 6, Time: 1000 + i*100})
+	}
+
+	p := NewPlayback(true)
+	if _, ok := p.Frame(frames); ok {
+		t.Fatal("Live playback should not serve buffered frames")
+	}
+
+	p.Rewind(frames, 500*time.Millisecond)
+	f, ok := p.Frame(frames)
+	if !ok || f.Tick != 24 {
+		t.Fatalf("Expected frame at tick 24 after rewind, got %d (ok=%v)", f.Tick, ok)
+	}
+
+	// Double speed covers 200ms of history per 100ms of real time
+	p.Speed = 2
+	p.Advance(100 * time.Millisecond)
+	if f, _ := p.Frame(frames); f.Tick != 36 {
+		t.Errorf("Expected tick 36 after advancing, got %d", f.Tick)
+	}
+
+	p.Paused = true
+	p.Advance(time.Second)
+	if f, _ := p.Frame(frames); f.Tick != 36 {
+		t.Errorf("Paused playback moved to tick %d", f.Tick)
+	}
+
+	p.Paused = false
+	p.Advance(time.Second)
+	if _, ok := p.Frame(frames); ok {
+		t.Error("Playback past the newest frame should report caught up")
+	}
+
+	p.Seek(frames, 20)
+	if f, _ := p.Frame(frames); f.Tick != 18 {
+		t.Errorf("Seek to tick 20 should land on frame 18, got %d", f.Tick)
+	}
+}
+
+func TestRecording_RoundTrip(t *testing.T) {
+	path := t.TempDir() + "/run.evr"
+	rec, err := NewRecorder(path, []byte(`{"terrain":null}`))
+	if err != nil {
+		t.Fatalf("NewRecorder failed: %v", err)
+	}
+	for i := int64(0); i < 3; i++ {
+		if err := rec.Write(Frame{Tick: i, Time: 1000 + i, Data: []byte{byte(i), 1, 2}}); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if string(loaded.Header) != `{"terrain":null}` {
+		t.Errorf("Header mismatch: %s", loaded.Header)
+	}
+	if len(loaded.Frames) != 3 {
+		t.Fatalf("Expected 3 frames, got %d", len(loaded.Frames))
+	}
+	if f := loaded.Frames[2]; f.Tick != 2 || f.Time != 1002 || f.Data[0] != 2 {
+		t.Errorf("Frame mismatch: %+v", f)
+	}
+}
@@ -0,0 +1,52 @@
+package replay
+
+import "sync"
+
+// Ring keeps the most recent frames in a fixed-size buffer. Older frames are
+// overwritten once it is full. Safe for concurrent use.
+type Ring struct {
+	mu     sync.RWMutex
+	frames []Frame
+	start  int // Index of the oldest frame
+	count  int
+}
+
+func NewRing(capacity int) *Ring {
+	return &Ring{frames: make([]Frame, capacity)}
+}
+
+// Push appends a frame, evicting the oldest one if the ring is full.
+func (r *Ring) Push(f Frame) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.frames) == 0 {
+		return
+	}
+	if r.count < len(r.frames) {
+		r.frames[(r.start+r.count)%len(r.frames)] = f
+		r.count++
+		return
+	}
+	r.frames[r.start] = f
+	r.start = (r.start + 1) % len(r.frames)
+}
+
+// Frames returns the buffered frames, oldest first. Frame data is shared and
+// must not be modified.
+func (r *Ring) Frames() []Frame {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]Frame, r.count)
+	for i := range out {
+		out[i] = r.frames[(r.start+i)%len(r.frames)]
+	}
+	return out
+}
+
+func (r *Ring) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.count
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"evo-sim/internal/brain"
+)
+
+// brainView is a creature's brain at one tick. Over WebSocket it is sent as
+// JSON text with Type "brain".
+type brainView struct {
+	Type       string       `json:"type,omitempty"`
+	CreatureID int          `json:"creatureId"`
+	Tick       int64        `json:"tick"`
+	Gone       bool         `json:"gone,omitempty"` // The creature died; the subscription ends
+	Inputs     []namedValue `json:"inputs,omitempty"`
+	Outputs    []namedValue `json:"outputs,omitempty"`
+	Graph      *brain.Graph `json:"graph,omitempty"`
+}
+
+type namedValue struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+}
+
+// inspectBrain describes the brain of creature id, or returns nil if it
+// isn't alive. The caller must hold s.World.Mu.
+func (s *Server) inspectBrain(id int) *brainView {
+	c := s.World.CreatureByID(id)
+	if c == nil {
+		return nil
+	}
+
+	g := c.Brain.Graph()
+	inputs := c.Inputs()
+	view := &brainView{CreatureID: id, Tick: s.World.Tick, Graph: &g}
+	for i, name := range s.World.Sensors.InputNames() {
+		var v float64
+		if i < len(inputs) {
+			v = inputs[i]
+		}
+		view.Inputs = append(view.Inputs, namedValue{Name: name, Value: v})
+	}
+	for i := range g.Nodes {
+		n := &g.Nodes[i]
+		switch {
+		case n.Kind == brain.NodeInput && n.ID < len(inputs):
+			n.Value = inputs[n.ID]
+		case n.Kind == brain.NodeOutput:
+			view.Outputs = append(view.Outputs, namedValue{Name: s.outputName(len(view.Outputs)), Value: n.Value})
+		}
+	}
+	return view
+}
+
+// oldestCreature returns the ID of the oldest living creature, 0 if there
+// are none. The caller must hold s.World.Mu.
+func (s *Server) oldestCreature() int {
+	id, age := 0, -1
+	for _, c := range s.World.Creatures {
+		if c.Age > age {
+			id, age = c.ID, c.Age
+		}
+	}
+	return id
+}
+
+func (s *Server) outputName(i int) string {
+	if names := s.World.OutputNames(); i < len(names) {
+		return names[i]
+	}
+	return "out" + strconv.Itoa(i)
+}
+
+// handleBrain serves /api/brain?id=N as JSON, or with format=dot as a
+// GraphViz diagram. Without an id it shows the oldest living creature.
+func (s *Server) handleBrain(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if s.World == nil {
+		http.Error(w, "recordings don't include brains", http.StatusNotFound)
+		return
+	}
+	id := 0
+	if param := r.URL.Query().Get("id"); param != "" {
+		var err error
+		if id, err = strconv.Atoi(param); err != nil {
+			http.Error(w, "id must be a creature ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	s.World.Mu.RLock()
+	if id == 0 {
+		id = s.oldestCreature()
+	}
+	view := s.inspectBrain(id)
+	s.World.Mu.RUnlock()
+	if view == nil {
+		http.Error(w, "no living creature with that ID", http.StatusNotFound)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "dot" {
+		w.Header().Set("Content-Type", "text/vnd.graphviz")
+		w.Header().Set("Content-Disposition", "attachment; filename=brain-"+strconv.Itoa(id)+".dot")
+		names := make([]string, len(view.Inputs))
+		for i, in := range view.Inputs {
+			names[i] = in.Name
+		}
+		brain.WriteDOT(w, *view.Graph, names, s.World.OutputNames())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(view)
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"encoding/binary"
+	"math"
+
+	"evo-sim/internal/entity"
+	"evo-sim/internal/world"
+)
+
+// frameVersion 2 added the creature rotation. Clients read it from /api/map;
+// recordings made before it have no version there.
+const frameVersion = 2
+
+// encodeFrame writes the current world state in the /ws binary format into
+// buf, growing it if needed. The caller must hold w.Mu.
+func encodeFrame(buf []byte, w *world.World) []byte {
+	creaturesCount := len(w.Creatures)
+	foodCount := len(w.Food)
+
+	// (2 bytes header) + (N * 19 bytes) + (2 bytes header) + (M * 8 bytes)
+	packetSize := 2 + (creaturesCount * 19) + 2 + (foodCount * 8)
+
+	// Resize buffer if needed
+	if cap(buf) < packetSize {
+		buf = make([]byte, packetSize*2) // Double capacity to avoid frequent reallocations
+	}
+	// Use the slice with correct length
+	packet := buf[:packetSize]
+	offset := 0
+
+	// === CREATURE SECTION ===
+	binary.LittleEndian.PutUint16(packet[offset:], uint16(creaturesCount))
+	offset += 2
+
+	for _, c := range w.Creatures {
+		// ID
+		binary.LittleEndian.PutUint16(packet[offset:], uint16(c.ID))
+		offset += 2
+		// X, Y
+		binary.LittleEndian.PutUint32(packet[offset:], math.Float32bits(float32(c.X)))
+		offset += 4
+		binary.LittleEndian.PutUint32(packet[offset:], math.Float32bits(float32(c.Y)))
+		offset += 4
+		// IsCarnivore (1 byte)
+		if c.IsCarnivore {
+			packet[offset] = 1
+		} else {
+			packet[offset] = 0
+		}
+		offset += 1
+		// Size (4 bytes)
+		binary.LittleEndian.PutUint32(packet[offset:], math.Float32bits(float32(c.Size)))
+		offset += 4
+		// Color (3 bytes)
+		packet[offset] = uint8(c.Genome.Express(entity.LocusColorR) * 255)
+		offset++
+		packet[offset] = uint8(c.Genome.Express(entity.LocusColorG) * 255)
+		offset++
+		packet[offset] = uint8(c.Genome.Express(entity.LocusColorB) * 255)
+		offset++
+		// Rotation (1 byte, 256ths of a turn)
+		packet[offset] = uint8(int(math.Round(c.Heading / (2 * math.Pi) * 256)))
+		offset++
+	}
+
+	// === FOOD SECTION ===
+	binary.LittleEndian.PutUint16(packet[offset:], uint16(foodCount))
+	offset += 2
+
+	for _, f := range w.Food {
+		// X
+		binary.LittleEndian.PutUint32(packet[offset:], math.Float32bits(float32(f.X)))
+		offset += 4
+		// Y
+		binary.LittleEndian.PutUint32(packet[offset:], math.Float32bits(float32(f.Y)))
+		offset += 4
+	}
+
+	return packet
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"evo-sim/internal/brain"
+	"evo-sim/internal/entity"
+	"evo-sim/internal/world"
+)
+
+// Spawn requests are small; a code with a brain is a few kilobytes.
+// maxSpawnCount bounds the creatures added by one request.
+const (
+	maxSpawnBody  = 1 << 20
+	maxSpawnCount = 100
+)
+
+// handleGenome serves /api/genome?id=N as a genome code, including the brain
+// with brain=1. Without an id it shows the oldest living creature.
+func (s *Server) handleGenome(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if s.World == nil {
+		http.Error(w, "recordings don't include genomes", http.StatusNotFound)
+		return
+	}
+	id := 0
+	if param := r.URL.Query().Get("id"); param != "" {
+		var err error
+		if id, err = strconv.Atoi(param); err != nil {
+			http.Error(w, "id must be a creature ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	s.World.Mu.RLock()
+	if id == 0 {
+		id = s.oldestCreature()
+	}
+	var code string
+	var err error
+	c := s.World.CreatureByID(id)
+	if c != nil {
+		b := c.Brain
+		if r.URL.Query().Get("brain") != "1" {
+			b = nil
+		}
+		code, err = entity.EncodeCode(c.Genome, b)
+	}
+	s.World.Mu.RUnlock()
+	if c == nil {
+		http.Error(w, "no living creature with that ID", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.WriteString(w, code+"\n")
+}
+
+// handleSpawn adds creatures from genome codes POSTed one per line, count=N
+// copies of each, and answers with their IDs. Nothing spawns unless every
+// code fits the world. Unlike the read-only endpoints it isn't open to other
+// origins.
+func (s *Server) handleSpawn(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST genome codes, one per line", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.World == nil {
+		http.Error(w, "can't spawn into a recording", http.StatusNotFound)
+		return
+	}
+	count := 1
+	if param := r.URL.Query().Get("count"); param != "" {
+		var err error
+		if count, err = strconv.Atoi(param); err != nil || count < 1 || count > maxSpawnCount {
+			http.Error(w, "count must be 1 to "+strconv.Itoa(maxSpawnCount), http.StatusBadRequest)
+			return
+		}
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSpawnBody))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	codes := strings.Fields(string(body))
+	if len(codes) == 0 {
+		http.Error(w, "no genome codes", http.StatusBadRequest)
+		return
+	}
+	if len(codes)*count > maxSpawnCount {
+		http.Error(w, "at most "+strconv.Itoa(maxSpawnCount)+" creatures per request", http.StatusBadRequest)
+		return
+	}
+
+	// Decode every copy before adding any, so none share a brain
+	type seed struct {
+		g entity.Genome
+		b brain.Brain
+	}
+	var seeds []seed
+	for _, code := range codes {
+		for n := 0; n < count; n++ {
+			g, b, err := entity.DecodeCode(code)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			seeds = append(seeds, seed{g, b})
+		}
+	}
+
+	s.World.Mu.Lock()
+	defer s.World.Mu.Unlock()
+
+	if err := world.CheckSeeds(codes, s.World.Sensors, len(s.World.OutputNames())); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ids := []int{}
+	for _, sd := range seeds {
+		c, err := s.World.Spawn(sd.g, sd.b)
+		if err != nil {
+			// CheckSeeds passed, so this is a bug rather than a bad code
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		ids = append(ids, c.ID)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		IDs []int `json:"ids"`
+	}{ids})
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"evo-sim/internal/replay"
+)
+
+// RecordHistory keeps the last length of world frames in memory, sampled
+// fps times per second, so clients can rewind the live view.
+func (s *Server) RecordHistory(length time.Duration, fps int) {
+	if length <= 0 || fps <= 0 {
+		return
+	}
+	s.History = replay.NewRing(int(length.Seconds() * float64(fps)))
+
+	go func() {
+		ticker := time.NewTicker(time.Second / time.Duration(fps))
+		defer ticker.Stop()
+
+		var buf []byte
+		for now := range ticker.C {
+			s.World.Mu.RLock()
+			tick := s.World.Tick
+			buf = encodeFrame(buf, s.World)
+			s.World.Mu.RUnlock()
+
+			// The ring keeps the frame, so it gets its own copy
+			data := append([]byte(nil), buf...)
+
+			s.History.Push(replay.Frame{Tick: tick, Time: now.UnixMilli(), Data: data})
+		}
+	}()
+}
+
+// Record writes the /ws frame stream to a file that NewReplayServer can play
+// back, at fps frames per second. Stop it with the returned function.
+func (s *Server) Record(path string, fps int) (stop func(), err error) {
+	s.World.Mu.RLock()
+	header, err := json.Marshal(s.mapResponse())
+	s.World.Mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	rec, err := replay.NewRecorder(path, header)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(time.Second / time.Duration(fps))
+		defer ticker.Stop()
+
+		var buf []byte
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				s.World.Mu.RLock()
+				tick := s.World.Tick
+				buf = encodeFrame(buf, s.World)
+				s.World.Mu.RUnlock()
+
+				if err := rec.Write(replay.Frame{Tick: tick, Time: now.UnixMilli(), Data: buf}); err != nil {
+					log.Println("Error recording frame:", err)
+					return
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-finished
+		if err := rec.Close(); err != nil {
+			log.Println("Error closing recording:", err)
+		}
+	}, nil
+}
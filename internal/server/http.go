@@ -4,21 +4,32 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"evo-sim/internal/replay"
 	"evo-sim/internal/world"
 )
 
 type Server struct {
-	World *world.World
+	World     *world.World
+	History   *replay.Ring      // Recent frames for rewinding, nil if disabled
+	Recording *replay.Recording // Set in replay mode, where World is nil
 }
 
 func NewServer(w *world.World) *Server {
 	return &Server{World: w}
 }
 
+// NewReplayServer serves a recording over the same protocol as a live world.
+func NewReplayServer(rec *replay.Recording) *Server {
+	return &Server{Recording: rec}
+}
+
 func (s *Server) Start(port string) error {
 	http.Handle("/", http.FileServer(http.Dir("./web")))
 	http.HandleFunc("/ws", s.handleWebSocket)
 	http.HandleFunc("/api/map", s.handleMap)
+	http.HandleFunc("/api/brain", s.handleBrain)
+	http.HandleFunc("/api/genome", s.handleGenome)
+	http.HandleFunc("/api/spawn", s.handleSpawn)
 
 	return http.ListenAndServe(":"+port, nil)
 }
@@ -28,16 +39,27 @@ func (s *Server) handleMap(w http.ResponseWriter, r *http.Request) {
 	// CORS for dev (if needed, otherwise can remove)
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 
+	if s.Recording != nil {
+		w.Write(s.Recording.Header)
+		return
+	}
+
 	s.World.Mu.RLock()
 	defer s.World.Mu.RUnlock()
 
-	response := struct {
-		Terrain   *world.TerrainGrid `json:"terrain"`
-		StartTime int64              `json:"startTime"` // Unix timestamp in milliseconds
+	json.NewEncoder(w).Encode(s.mapResponse())
+}
+
+// mapResponse is the static map data. Recordings store it as their header.
+// The caller must hold s.World.Mu.
+func (s *Server) mapResponse() interface{} {
+	return struct {
+		Terrain      *world.TerrainGrid `json:"terrain"`
+		StartTime    int64              `json:"startTime"` // Unix timestamp in milliseconds
+		FrameVersion int                `json:"frameVersion"`
 	}{
-		Terrain:   s.World.Terrain,
-		StartTime: s.World.StartTime.UnixMilli(),
+		Terrain:      s.World.Terrain,
+		StartTime:    s.World.StartTime.UnixMilli(),
+		FrameVersion: frameVersion,
 	}
-
-	json.NewEncoder(w).Encode(response)
 }
@@ -1,13 +1,15 @@
 package server
 
 import (
-	"encoding/binary"
+	"encoding/json"
+	"fmt"
 	"log"
-	"math"
 	"net/http"
 	"time"
 
 	"github.com/gorilla/websocket"
+
+	"evo-sim/internal/replay"
 )
 
 var upgrader = websocket.Upgrader{
@@ -17,6 +19,38 @@ var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool { return true },
 }
 
+// command is a control message sent by the client as JSON text, e.g.
+// {"cmd":"rewind","seconds":30}. {"cmd":"inspect","id":N} subscribes to the
+// brain of creature N, and id 0 unsubscribes.
+type command struct {
+	Cmd     string  `json:"cmd"` // rewind, seek, pause, play, speed, live, inspect
+	Seconds float64 `json:"seconds,omitempty"`
+	Tick    int64   `json:"tick,omitempty"`
+	Speed   float64 `json:"speed,omitempty"`
+	ID      int     `json:"id,omitempty"`
+
+	err error // Set instead of the fields for a message that isn't a command
+}
+
+// commandError answers a message that couldn't be read as a command. The web
+// client only acts on playback status, so it ignores these.
+type commandError struct {
+	Type  string `json:"type"` // "error"
+	Error string `json:"error"`
+}
+
+// playbackStatus tells the client where it is in the timeline. It is sent as
+// JSON text between the binary frames.
+type playbackStatus struct {
+	Type       string  `json:"type"`
+	Live       bool    `json:"live"`
+	Paused     bool    `json:"paused"`
+	Speed      float64 `json:"speed"`
+	Tick       int64   `json:"tick"`
+	OldestTick int64   `json:"oldestTick"`
+	NewestTick int64   `json:"newestTick"`
+}
+
 func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -27,80 +61,188 @@ func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 
 	log.Println("New client connected via WebSockets")
 
+	commands := make(chan command, 8)
+	go readCommands(conn, commands)
+
 	ticker := time.NewTicker(33 * time.Millisecond)
 	defer ticker.Stop()
+	statusTicker := time.NewTicker(time.Second)
+	defer statusTicker.Stop()
 
 	// Pre-allocate a buffer with reasonable initial size (e.g., for 500 creatures + 500 food)
-	// 500 * 18 + 500 * 8 + 4 = 13004 bytes. Let's start with 16KB.
+	// 500 * 19 + 500 * 8 + 4 = 13504 bytes. Let's start with 16KB.
 	buf := make([]byte, 16384)
 
-	for range ticker.C {
-		s.World.Mu.RLock()
+	// Recordings start from the beginning, live worlds from the present
+	playback := replay.NewPlayback(s.World != nil)
+	if s.Recording != nil {
+		playback.Seek(s.Recording.Frames, 0)
+	}
+	var tick int64
+	held := false // buf holds a live frame
+	last := time.Now()
+
+	// Brain subscription, see command
+	inspecting := 0
+	var inspectedTick int64 = -1
+
+	for {
+		select {
+		case cmd, ok := <-commands:
+			if !ok {
+				return
+			}
+			if cmd.err != nil {
+				if err := conn.WriteJSON(commandError{Type: "error", Error: cmd.err.Error()}); err != nil {
+					return
+				}
+				continue
+			}
+			if cmd.Cmd == "inspect" {
+				inspecting, inspectedTick = cmd.ID, -1
+				continue
+			}
+			s.applyCommand(playback, cmd)
+			if err := s.sendStatus(conn, playback, tick); err != nil {
+				return
+			}
 
-		creaturesCount := len(s.World.Creatures)
-		foodCount := len(s.World.Food)
+		case <-statusTicker.C:
+			if err := s.sendStatus(conn, playback, tick); err != nil {
+				return
+			}
 
-		// (2 bytes header) + (N * 18 bytes) + (2 bytes header) + (M * 8 bytes)
-		packetSize := 2 + (creaturesCount * 18) + 2 + (foodCount * 8)
-		
-		// Resize buffer if needed
-		if cap(buf) < packetSize {
-			buf = make([]byte, packetSize * 2) // Double capacity to avoid frequent reallocations
-		}
-		// Use the slice with correct length
-		packet := buf[:packetSize]
-		offset := 0
-
-		// === CREATURE SECTION ===
-		binary.LittleEndian.PutUint16(packet[offset:], uint16(creaturesCount))
-		offset += 2
-
-		for _, c := range s.World.Creatures {
-			// ID
-			binary.LittleEndian.PutUint16(packet[offset:], uint16(c.ID))
-			offset += 2
-			// X, Y
-			binary.LittleEndian.PutUint32(packet[offset:], math.Float32bits(float32(c.X)))
-			offset += 4
-			binary.LittleEndian.PutUint32(packet[offset:], math.Float32bits(float32(c.Y)))
-			offset += 4
-			// IsCarnivore (1 byte)
-			if c.IsCarnivore {
-				packet[offset] = 1
+		case now := <-ticker.C:
+			playback.Advance(now.Sub(last))
+			last = now
+
+			var packet []byte
+			if frame, ok := playback.Frame(s.historyFrames()); ok {
+				packet, tick = frame.Data, frame.Tick
+			} else if s.World == nil {
+				// End of the recording: hold the last frame
+				if len(s.Recording.Frames) == 0 {
+					continue
+				}
+				s.applyCommand(playback, command{Cmd: "live"})
+				playback.Paused = true
+				frame := s.Recording.Frames[len(s.Recording.Frames)-1]
+				packet, tick = frame.Data, frame.Tick
+			} else if playback.Live && playback.Paused && held {
+				// Paused without history to rewind into: hold the last
+				// live frame
+				packet = buf
 			} else {
-				packet[offset] = 0
+				// Caught up with the present (or never left it)
+				playback.Live = true
+				s.World.Mu.RLock()
+				buf = encodeFrame(buf, s.World)
+				tick = s.World.Tick
+				s.World.Mu.RUnlock()
+				packet, held = buf, true
+			}
+
+			if err := conn.WriteMessage(websocket.BinaryMessage, packet); err != nil {
+				log.Printf("Client disconnected: %v", err)
+				return
 			}
-			offset += 1
-			// Size (4 bytes)
-			binary.LittleEndian.PutUint32(packet[offset:], math.Float32bits(float32(c.Size)))
-			offset += 4
-			// Color (3 bytes)
-			packet[offset] = uint8(c.Genome.ColorR * 255)
-			offset++
-			packet[offset] = uint8(c.Genome.ColorG * 255)
-			offset++
-			packet[offset] = uint8(c.Genome.ColorB * 255)
-			offset++
-		}
 
-		// === FOOD SECTION ===
-		binary.LittleEndian.PutUint16(packet[offset:], uint16(foodCount))
-		offset += 2
-
-		for _, f := range s.World.Food {
-			// X
-			binary.LittleEndian.PutUint32(packet[offset:], math.Float32bits(float32(f.X)))
-			offset += 4
-			// Y
-			binary.LittleEndian.PutUint32(packet[offset:], math.Float32bits(float32(f.Y)))
-			offset += 4
+			// The brain is always live, even while the map is rewound
+			if inspecting != 0 && s.World != nil {
+				s.World.Mu.RLock()
+				view, current := s.inspectBrain(inspecting), s.World.Tick
+				s.World.Mu.RUnlock()
+				if current == inspectedTick {
+					continue
+				}
+				inspectedTick = current
+				if view == nil {
+					view = &brainView{CreatureID: inspecting, Tick: current, Gone: true}
+					inspecting = 0
+				}
+				view.Type = "brain"
+				if err := conn.WriteJSON(view); err != nil {
+					return
+				}
+			}
 		}
+	}
+}
 
-		s.World.Mu.RUnlock()
+// readCommands forwards client control messages until the connection closes.
+// Messages that don't decode as a command are forwarded with err set.
+func readCommands(conn *websocket.Conn, commands chan<- command) {
+	defer close(commands)
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var cmd command
+		if err := json.Unmarshal(data, &cmd); err != nil {
+			cmd = command{err: fmt.Errorf("bad command: %w", err)}
+		}
+		commands <- cmd
+	}
+}
 
-		if err := conn.WriteMessage(websocket.BinaryMessage, packet); err != nil {
-			log.Printf("Client disconnected: %v", err)
-			break
+func (s *Server) applyCommand(p *replay.Playback, cmd command) {
+	frames := s.historyFrames()
+	switch cmd.Cmd {
+	case "rewind":
+		if p.Live {
+			p.Rewind(frames, time.Duration(cmd.Seconds*float64(time.Second)))
+		} else if len(frames) > 0 {
+			p.Position -= cmd.Seconds * 1000
+		}
+	case "seek":
+		p.Seek(frames, cmd.Tick)
+	case "pause":
+		if p.Live {
+			// Freeze on the newest buffered frame, or without history on the
+			// last live frame sent
+			p.Rewind(frames, 0)
+		}
+		p.Paused = true
+	case "play":
+		p.Paused = false
+	case "speed":
+		if cmd.Speed > 0 {
+			p.Speed = cmd.Speed
+		}
+	case "live":
+		if s.World == nil {
+			// A recording has no present: jump to its end
+			if len(frames) > 0 {
+				p.Seek(frames, frames[len(frames)-1].Tick)
+			}
+			return
 		}
+		p.Live, p.Paused = true, false
+	}
+}
+
+func (s *Server) sendStatus(conn *websocket.Conn, p *replay.Playback, tick int64) error {
+	status := playbackStatus{
+		Type:   "playback",
+		Live:   p.Live,
+		Paused: p.Paused,
+		Speed:  p.Speed,
+		Tick:   tick,
+	}
+	if frames := s.historyFrames(); len(frames) > 0 {
+		status.OldestTick = frames[0].Tick
+		status.NewestTick = frames[len(frames)-1].Tick
+	}
+	return conn.WriteJSON(status)
+}
+
+func (s *Server) historyFrames() []replay.Frame {
+	if s.Recording != nil {
+		return s.Recording.Frames
+	}
+	if s.History == nil {
+		return nil
 	}
+	return s.History.Frames()
 }
@@ -0,0 +1,386 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"evo-sim/internal/brain"
+	"evo-sim/internal/entity"
+	"evo-sim/internal/world"
+)
+
+// Snapshot encodings accepted by SaveSnapshot.
+const (
+	FormatJSON   = "json"
+	FormatBinary = "binary"
+)
+
+// binaryMagic prefixes gzip-compressed binary snapshots so they can share the
+// data column with older JSON rows.
+var binaryMagic = []byte("EVSB")
+
+// binaryVersion is bumped when the binary layout changes. Genomes are
+// written against a table of locus names, so adding loci doesn't need one.
+const binaryVersion = 1
+
+// CheckFormat reports whether format is a known snapshot encoding. Empty
+// means FormatJSON.
+func CheckFormat(format string) error {
+	switch format {
+	case FormatJSON, FormatBinary, "":
+		return nil
+	default:
+		return fmt.Errorf("unknown snapshot format %q", format)
+	}
+}
+
+// EncodeSnapshot serializes a snapshot in the given format.
+func EncodeSnapshot(snapshot *WorldSnapshot, format string) ([]byte, error) {
+	switch format {
+	case FormatJSON, "":
+		return json.Marshal(snapshot)
+	case FormatBinary:
+		return encodeBinary(snapshot)
+	default:
+		return nil, fmt.Errorf("unknown snapshot format %q", format)
+	}
+}
+
+// DecodeSnapshot detects the encoding of a stored snapshot and decodes it.
+// JSON snapshots of older layouts are upgraded on the fly.
+func DecodeSnapshot(data []byte) (*WorldSnapshot, error) {
+	if IsBinarySnapshot(data) {
+		return decodeBinary(data)
+	}
+
+	version, err := snapshotVersion(data)
+	if err != nil {
+		return nil, err
+	}
+	if version < CurrentSnapshotVersion {
+		if data, err = upgradeSnapshotJSON(data, version); err != nil {
+			return nil, err
+		}
+	}
+
+	var snapshot WorldSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// IsBinarySnapshot reports whether data was produced by the binary encoder.
+func IsBinarySnapshot(data []byte) bool {
+	return bytes.HasPrefix(data, binaryMagic)
+}
+
+func encodeBinary(snapshot *WorldSnapshot) ([]byte, error) {
+	var out bytes.Buffer
+	out.Write(binaryMagic)
+	out.WriteByte(binaryVersion)
+
+	zw := gzip.NewWriter(&out)
+	w := &binWriter{w: zw}
+
+	w.int64(snapshot.Timestamp)
+	w.int64(snapshot.Tick)
+
+	// Stats in key order so identical snapshots encode identically
+	keys := make([]string, 0, len(snapshot.Stats))
+	for k := range snapshot.Stats {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	w.uint32(uint32(len(keys)))
+	for _, k := range keys {
+		w.string(k)
+		w.int64(int64(snapshot.Stats[k]))
+	}
+
+	w.uint32(entity.NumLoci)
+	for _, l := range entity.Loci {
+		w.string(l.Name)
+	}
+	w.uint32(uint32(len(snapshot.Creatures)))
+	for _, c := range snapshot.Creatures {
+		w.creature(c)
+	}
+
+	w.uint32(uint32(len(snapshot.Food)))
+	for _, f := range snapshot.Food {
+		w.int64(int64(f.ID))
+		w.float64(f.X)
+		w.float64(f.Y)
+		w.float64(f.Energy)
+		w.int64(int64(f.DecayTicks))
+	}
+
+	w.bool(snapshot.Terrain != nil)
+	if t := snapshot.Terrain; t != nil {
+		w.uint32(uint32(t.Width))
+		w.uint32(uint32(t.Height))
+		w.float64(t.Scale)
+		cells := make([]byte, len(t.Cells))
+		for i, c := range t.Cells {
+			cells[i] = byte(c)
+		}
+		w.bytes(cells)
+	}
+
+	if w.err != nil {
+		return nil, w.err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func decodeBinary(data []byte) (*WorldSnapshot, error) {
+	header := len(binaryMagic)
+	if len(data) <= header {
+		return nil, errors.New("binary snapshot is truncated")
+	}
+	version := data[header]
+	if version != binaryVersion {
+		return nil, fmt.Errorf("unsupported binary snapshot version %d", version)
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(data[header+1:]))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	r := &binReader{r: zr}
+
+	snapshot := &WorldSnapshot{Version: CurrentSnapshotVersion, Timestamp: r.int64(), Tick: r.int64()}
+
+	statCount := r.uint32()
+	snapshot.Stats = make(map[string]int, statCount)
+	for i := uint32(0); i < statCount && r.err == nil; i++ {
+		k := r.string()
+		snapshot.Stats[k] = int(r.int64())
+	}
+
+	// Loci by the names they were written under, -1 for unknown ones
+	var loci []int
+	lociCount := r.uint32()
+	for i := uint32(0); i < lociCount && r.err == nil; i++ {
+		l, ok := entity.LocusIndex(r.string())
+		if !ok {
+			l = -1
+		}
+		loci = append(loci, l)
+	}
+	creatureCount := r.uint32()
+	for i := uint32(0); i < creatureCount && r.err == nil; i++ {
+		snapshot.Creatures = append(snapshot.Creatures, r.creature(loci))
+	}
+
+	foodCount := r.uint32()
+	for i := uint32(0); i < foodCount && r.err == nil; i++ {
+		snapshot.Food = append(snapshot.Food, entity.Food{
+			ID:         int(r.int64()),
+			X:          r.float64(),
+			Y:          r.float64(),
+			Energy:     r.float64(),
+			DecayTicks: int(r.int64()),
+		})
+	}
+
+	if r.bool() {
+		t := &world.TerrainGrid{
+			Width:  int(r.uint32()),
+			Height: int(r.uint32()),
+			Scale:  r.float64(),
+		}
+		for _, c := range r.bytes() {
+			t.Cells = append(t.Cells, world.TerrainType(c))
+		}
+		snapshot.Terrain = t
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+	return snapshot, nil
+}
+
+// binWriter writes little-endian values and remembers the first error.
+type binWriter struct {
+	w   io.Writer
+	err error
+	tmp [8]byte
+}
+
+func (w *binWriter) write(b []byte) {
+	if w.err == nil {
+		_, w.err = w.w.Write(b)
+	}
+}
+
+func (w *binWriter) uint32(v uint32) {
+	binary.LittleEndian.PutUint32(w.tmp[:4], v)
+	w.write(w.tmp[:4])
+}
+
+func (w *binWriter) int64(v int64) {
+	binary.LittleEndian.PutUint64(w.tmp[:], uint64(v))
+	w.write(w.tmp[:])
+}
+
+func (w *binWriter) float64(v float64) {
+	binary.LittleEndian.PutUint64(w.tmp[:], math.Float64bits(v))
+	w.write(w.tmp[:])
+}
+
+func (w *binWriter) bytes(b []byte) {
+	w.uint32(uint32(len(b)))
+	w.write(b)
+}
+
+func (w *binWriter) string(s string) {
+	w.bytes([]byte(s))
+}
+
+func (w *binWriter) bool(v bool) {
+	if v {
+		w.write([]byte{1})
+	} else {
+		w.write([]byte{0})
+	}
+}
+
+func (w *binWriter) creature(c *entity.Creature) {
+	w.int64(int64(c.ID))
+	w.int64(int64(c.SpeciesID))
+	w.int64(int64(c.Generation))
+	w.float64(c.X)
+	w.float64(c.Y)
+	w.float64(c.Energy)
+	w.float64(c.Size)
+	w.float64(c.Mass)
+	w.float64(c.Speed)
+	w.float64(c.ViewRadius)
+	w.bool(c.IsCarnivore)
+	w.float64(c.BMR)
+	w.float64(c.MaxEnergy)
+	w.float64(c.ReproductionThreshold)
+	w.int64(int64(c.Age))
+
+	for _, a := range c.Genome.Alleles {
+		w.float64(a[0])
+		w.float64(a[1])
+	}
+	w.string(c.Genome.Sensors)
+	w.float64(c.Heading)
+	w.float64(c.VX)
+	w.float64(c.VY)
+
+	var brainData []byte
+	if c.Brain != nil {
+		data, err := c.Brain.MarshalBinary()
+		if err != nil && w.err == nil {
+			w.err = err
+		}
+		brainData = data
+	}
+	w.bytes(brainData)
+}
+
+// binReader mirrors binWriter.
+type binReader struct {
+	r   io.Reader
+	err error
+	tmp [8]byte
+}
+
+func (r *binReader) read(n int) []byte {
+	if r.err != nil {
+		return r.tmp[:n]
+	}
+	_, r.err = io.ReadFull(r.r, r.tmp[:n])
+	return r.tmp[:n]
+}
+
+func (r *binReader) uint32() uint32 {
+	return binary.LittleEndian.Uint32(r.read(4))
+}
+
+func (r *binReader) int64() int64 {
+	return int64(binary.LittleEndian.Uint64(r.read(8)))
+}
+
+func (r *binReader) float64() float64 {
+	return math.Float64frombits(binary.LittleEndian.Uint64(r.read(8)))
+}
+
+func (r *binReader) bytes() []byte {
+	n := r.uint32()
+	if r.err != nil || n == 0 {
+		return nil
+	}
+	// Read as far as the data goes rather than trusting n for the allocation
+	b, err := io.ReadAll(io.LimitReader(r.r, int64(n)))
+	if err == nil && len(b) < int(n) {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		r.err = err
+	}
+	return b
+}
+
+func (r *binReader) string() string {
+	return string(r.bytes())
+}
+
+func (r *binReader) bool() bool {
+	return r.read(1)[0] == 1
+}
+
+// creature reads a creature whose alleles are stored for the given loci, -1
+// for ones this build doesn't know.
+func (r *binReader) creature(loci []int) *entity.Creature {
+	c := &entity.Creature{}
+	c.ID = int(r.int64())
+	c.SpeciesID = int(r.int64())
+	c.Generation = int(r.int64())
+	c.X = r.float64()
+	c.Y = r.float64()
+	c.Energy = r.float64()
+	c.Size = r.float64()
+	c.Mass = r.float64()
+	c.Speed = r.float64()
+	c.ViewRadius = r.float64()
+	c.IsCarnivore = r.bool()
+	c.BMR = r.float64()
+	c.MaxEnergy = r.float64()
+	c.ReproductionThreshold = r.float64()
+	c.Age = int(r.int64())
+
+	c.Genome = entity.DefaultGenome()
+	for _, l := range loci {
+		a := [2]float64{r.float64(), r.float64()}
+		if l >= 0 {
+			c.Genome.Alleles[l] = a
+		}
+	}
+	c.Genome.Sensors = r.string()
+	c.Heading = r.float64()
+	c.VX = r.float64()
+	c.VY = r.float64()
+
+	if brainData := r.bytes(); brainData != nil && r.err == nil {
+		c.Brain, r.err = brain.DecodeBinary(brainData)
+	}
+	return c
+}
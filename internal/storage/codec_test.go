@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"evo-sim/internal/brain"
+	"evo-sim/internal/entity"
+	"evo-sim/internal/world"
+)
+
+func TestEncodeSnapshot_BinaryRoundTrip(t *testing.T) {
+	snapshot := &WorldSnapshot{
+		Timestamp: 1700000000,
+		Tick:      12345,
+		Terrain:   &world.TerrainGrid{Width: 2, Height: 1, Scale: 20, Cells: []world.TerrainType{world.Water, world.Grass}},
+		Stats:     map[string]int{"creatures_count": 30, "food_count": 2},
+		Food: []entity.Food{
+			{ID: 1, X: 10, Y: 20},
+			{ID: 2, X: 30, Y: 40, Energy: 55, DecayTicks: 100},
+		},
+	}
+	for i := 0; i < 30; i++ {
+		snapshot.Creatures = append(snapshot.Creatures, entity.NewCreature(i, float64(i), float64(i*2), brain.Spec{Type: brain.TypeElman}, 11, 2, 0.005, 0))
+	}
+	snapshot.Creatures[7].Genome.Sensors = "food,energy,clock"
+
+	jsonData, err := EncodeSnapshot(snapshot, FormatJSON)
+	if err != nil {
+		t.Fatalf("JSON encode: %v", err)
+	}
+	binData, err := EncodeSnapshot(snapshot, FormatBinary)
+	if err != nil {
+		t.Fatalf("Binary encode: %v", err)
+	}
+	if len(binData)*2 > len(jsonData) {
+		t.Errorf("Binary snapshot should be much smaller than JSON: %d vs %d bytes", len(binData), len(jsonData))
+	}
+
+	decoded, err := DecodeSnapshot(binData)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if decoded.Timestamp != snapshot.Timestamp || decoded.Tick != 12345 || decoded.Stats["creatures_count"] != 30 {
+		t.Errorf("Header mismatch: %+v", decoded)
+	}
+	if len(decoded.Creatures) != 30 || len(decoded.Food) != 2 {
+		t.Fatalf("Counts: got %d creatures, %d food", len(decoded.Creatures), len(decoded.Food))
+	}
+
+	orig, got := snapshot.Creatures[7], decoded.Creatures[7]
+	if got.ID != orig.ID || got.X != orig.X || got.Genome != orig.Genome {
+		t.Errorf("Creature mismatch: got %+v, want %+v", got, orig)
+	}
+	in := []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 1.0, 0.0}
+	if a, b := orig.Brain.FeedForward(in)[0], got.Brain.FeedForward(in)[0]; a != b {
+		t.Errorf("Restored brain output: got %f, want %f", b, a)
+	}
+	if decoded.Terrain == nil || decoded.Terrain.Width != 2 || decoded.Terrain.Cells[1] != world.Grass {
+		t.Errorf("Terrain mismatch: %+v", decoded.Terrain)
+	}
+	if decoded.Food[1] != snapshot.Food[1] {
+		t.Errorf("Food mismatch: got %+v, want %+v", decoded.Food[1], snapshot.Food[1])
+	}
+}
+
+func TestDecodeSnapshot_UpgradesFieldGenomes(t *testing.T) {
+	data := []byte(`{"version":1,"timestamp":1,"stats":{},"food":[],"creatures":[{"ID":3,"Genome":{
+		"SizeAllele1":1.5,"SizeAllele2":2,"SenseAllele1":80,"SenseAllele2":90,
+		"LayerAlleles":[[5,7],[0,0],[0,0]],"ColorG":0.25,"Sensors":"food,energy"}}]}`)
+	snapshot, err := DecodeSnapshot(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := snapshot.Creatures[0].Genome
+	if g.Alleles[entity.LocusSize] != [2]float64{1.5, 2} || g.ExpressedSense() != 85 {
+		t.Errorf("alleles not moved to loci: %+v", g)
+	}
+	if g.Alleles[entity.LocusLayer2] != [2]float64{5, 7} || g.Express(entity.LocusColorG) != 0.25 || g.Sensors != "food,energy" {
+		t.Errorf("layers, color or sensors lost: %+v", g)
+	}
+	if g.ExpressedDiet() != entity.Loci[entity.LocusDiet].Default || g.ExpressedFOV() != entity.DefaultFOV {
+		t.Errorf("missing loci didn't default: %+v", g)
+	}
+}
+
+func TestRetentionPolicy_Expired(t *testing.T) {
+	now := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+	policy := RetentionPolicy{Hourly: 24 * time.Hour, Daily: 30 * 24 * time.Hour}
+
+	// One snapshot every 15 minutes for 60 days
+	var infos []SnapshotInfo
+	for i := int64(0); i < 60*24*4; i++ {
+		infos = append(infos, SnapshotInfo{ID: i + 1, CreatedAt: now.Add(-time.Duration(i) * 15 * time.Minute)})
+	}
+
+	expired := policy.Expired(infos, now)
+	kept := len(infos) - len(expired)
+
+	// ~24 hourly + ~29 daily + ~5 weekly
+	if kept < 50 || kept > 70 {
+		t.Errorf("Kept %d snapshots, expected roughly 58", kept)
+	}
+	for _, id := range expired {
+		if id == 1 {
+			t.Fatal("Newest snapshot must never expire")
+		}
+	}
+
+	policy.Weekly = 45 * 24 * time.Hour
+	if bounded := policy.Expired(infos, now); len(bounded) <= len(expired) {
+		t.Errorf("Bounded weekly tier should expire more snapshots: %d vs %d", len(bounded), len(expired))
+	}
+}
@@ -0,0 +1,278 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"evo-sim/internal/world"
+)
+
+// DirStorage keeps history as plain files in a directory:
+//
+//	snapshots/<id>-<unix nanos>.snap   one encoded WorldSnapshot per file
+//	stats.ndjson, events.ndjson, lineage.ndjson   append-only records
+//	runs.ndjson   run descriptions
+//
+// The default run lives in the root directory, other runs in runs/<id>/.
+type DirStorage struct {
+	Root   string // Top-level directory shared by all runs
+	Dir    string // Directory of this run
+	Format string
+	RunID  string
+
+	mu     sync.Mutex
+	nextID int64
+}
+
+const snapshotExt = ".snap"
+
+func NewDirStorage(root, format, run string) (*DirStorage, error) {
+	if err := CheckFormat(format); err != nil {
+		return nil, err
+	}
+	if err := CheckRunID(run); err != nil {
+		return nil, err
+	}
+	dir := root
+	if run != DefaultRun {
+		dir = filepath.Join(root, "runs", run)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "snapshots"), 0o755); err != nil {
+		return nil, err
+	}
+
+	d := &DirStorage{Root: root, Dir: dir, Format: format, RunID: run, nextID: 1}
+	infos, err := d.ListSnapshots()
+	if err != nil {
+		return nil, err
+	}
+	if len(infos) > 0 {
+		d.nextID = infos[len(infos)-1].ID + 1
+	}
+	return d, nil
+}
+
+func (d *DirStorage) Close() error {
+	return nil
+}
+
+func (d *DirStorage) Run() string {
+	return d.RunID
+}
+
+func (d *DirStorage) SaveRun(run RunInfo) error {
+	if err := CheckRunID(run.ID); err != nil {
+		return err
+	}
+	if run.CreatedAt.IsZero() {
+		run.CreatedAt = time.Now()
+	}
+	return appendRecords(d, filepath.Join(d.Root, "runs.ndjson"), []RunInfo{run})
+}
+
+func (d *DirStorage) ListRuns() ([]RunInfo, error) {
+	runs := []RunInfo{{ID: DefaultRun}}
+	index := map[string]int{DefaultRun: 0}
+	err := readRecords(filepath.Join(d.Root, "runs.ndjson"), func(run RunInfo) {
+		if i, ok := index[run.ID]; ok {
+			runs[i] = run
+			return
+		}
+		index[run.ID] = len(runs)
+		runs = append(runs, run)
+	})
+	return runs, err
+}
+
+func (d *DirStorage) SaveSnapshot(snapshot *WorldSnapshot) (int64, error) {
+	data, err := EncodeSnapshot(snapshot, d.Format)
+	if err != nil {
+		return 0, err
+	}
+
+	d.mu.Lock()
+	id := d.nextID
+	d.nextID++
+	d.mu.Unlock()
+
+	name := fmt.Sprintf("%08d-%d%s", id, time.Now().UnixNano(), snapshotExt)
+	if err := os.WriteFile(filepath.Join(d.Dir, "snapshots", name), data, 0o644); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (d *DirStorage) ListSnapshots() ([]SnapshotInfo, error) {
+	entries, err := os.ReadDir(filepath.Join(d.Dir, "snapshots"))
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []SnapshotInfo
+	for _, entry := range entries {
+		id, created, ok := parseSnapshotName(entry.Name())
+		if !ok {
+			continue
+		}
+		size := 0
+		if fi, err := entry.Info(); err == nil {
+			size = int(fi.Size())
+		}
+		infos = append(infos, SnapshotInfo{ID: id, CreatedAt: created, Size: size})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos, nil
+}
+
+func (d *DirStorage) LoadSnapshot(id int64) (*WorldSnapshot, error) {
+	path, err := d.snapshotPath(id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeSnapshot(data)
+}
+
+func (d *DirStorage) DeleteSnapshots(ids []int64) error {
+	for _, id := range ids {
+		path, err := d.snapshotPath(id)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *DirStorage) snapshotPath(id int64) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(d.Dir, "snapshots", fmt.Sprintf("%08d-*%s", id, snapshotExt)))
+	if err != nil {
+		return "", err
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf("snapshot %d not found", id)
+	}
+	return matches[0], nil
+}
+
+func parseSnapshotName(name string) (int64, time.Time, bool) {
+	base, ok := strings.CutSuffix(name, snapshotExt)
+	if !ok {
+		return 0, time.Time{}, false
+	}
+	idStr, nanosStr, ok := strings.Cut(base, "-")
+	if !ok {
+		return 0, time.Time{}, false
+	}
+	id, err1 := strconv.ParseInt(idStr, 10, 64)
+	nanos, err2 := strconv.ParseInt(nanosStr, 10, 64)
+	if err1 != nil || err2 != nil {
+		return 0, time.Time{}, false
+	}
+	return id, time.Unix(0, nanos), true
+}
+
+func (d *DirStorage) SaveStats(st StatsRecord) error {
+	return appendRecords(d, filepath.Join(d.Dir, "stats.ndjson"), []StatsRecord{st})
+}
+
+func (d *DirStorage) ListStats() ([]StatsRecord, error) {
+	var stats []StatsRecord
+	err := readRecords(filepath.Join(d.Dir, "stats.ndjson"), func(st StatsRecord) {
+		stats = append(stats, st)
+	})
+	return stats, err
+}
+
+func (d *DirStorage) SaveEvents(events []world.Event) error {
+	return appendRecords(d, filepath.Join(d.Dir, "events.ndjson"), events)
+}
+
+func (d *DirStorage) ListEvents(fromTick, toTick int64) ([]world.Event, error) {
+	var events []world.Event
+	err := readRecords(filepath.Join(d.Dir, "events.ndjson"), func(e world.Event) {
+		if e.Tick >= fromTick && e.Tick < toTick {
+			events = append(events, e)
+		}
+	})
+	return events, err
+}
+
+func (d *DirStorage) SaveLineage(records []LineageRecord) error {
+	return appendRecords(d, filepath.Join(d.Dir, "lineage.ndjson"), records)
+}
+
+func (d *DirStorage) Ancestors(creatureID int) ([]LineageRecord, error) {
+	index := make(map[int]LineageRecord)
+	err := readRecords(filepath.Join(d.Dir, "lineage.ndjson"), func(r LineageRecord) {
+		index[r.CreatureID] = r
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ancestors(index, creatureID), nil
+}
+
+// appendRecords writes records as newline-delimited JSON to path.
+func appendRecords[T any](d *DirStorage, path string, records []T) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readRecords decodes every line of an NDJSON file. A missing file is empty.
+func readRecords[T any](path string, fn func(T)) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(bufio.NewReader(f))
+	for dec.More() {
+		var r T
+		if err := dec.Decode(&r); err != nil {
+			return err
+		}
+		fn(r)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"evo-sim/internal/world"
+)
+
+// MemoryStorage keeps everything in process memory. Useful for tests and
+// headless runs that don't need history on disk.
+type MemoryStorage struct {
+	runID string
+	runs  []RunInfo
+
+	mu        sync.RWMutex
+	nextID    int64
+	snapshots map[int64]memorySnapshot
+	stats     []StatsRecord
+	events    []world.Event
+	lineage   map[int]LineageRecord
+}
+
+type memorySnapshot struct {
+	info SnapshotInfo
+	data []byte // Binary encoding, decoded afresh on every load
+}
+
+func NewMemoryStorage(run string) *MemoryStorage {
+	return &MemoryStorage{
+		runID:     run,
+		nextID:    1,
+		snapshots: make(map[int64]memorySnapshot),
+		lineage:   make(map[int]LineageRecord),
+	}
+}
+
+func (m *MemoryStorage) Close() error {
+	return nil
+}
+
+func (m *MemoryStorage) Run() string {
+	return m.runID
+}
+
+func (m *MemoryStorage) SaveRun(run RunInfo) error {
+	if err := CheckRunID(run.ID); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if run.CreatedAt.IsZero() {
+		run.CreatedAt = time.Now()
+	}
+	for i := range m.runs {
+		if m.runs[i].ID == run.ID {
+			m.runs[i] = run
+			return nil
+		}
+	}
+	m.runs = append(m.runs, run)
+	return nil
+}
+
+func (m *MemoryStorage) ListRuns() ([]RunInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return append([]RunInfo(nil), m.runs...), nil
+}
+
+// SaveSnapshot stores the snapshot encoded, so neither later simulation
+// updates nor changes to loaded copies alter it.
+func (m *MemoryStorage) SaveSnapshot(snapshot *WorldSnapshot) (int64, error) {
+	data, err := EncodeSnapshot(snapshot, FormatBinary)
+	if err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := m.nextID
+	m.nextID++
+	m.snapshots[id] = memorySnapshot{
+		info: SnapshotInfo{ID: id, CreatedAt: time.Now(), Size: len(data)},
+		data: data,
+	}
+	return id, nil
+}
+
+// LoadSnapshot returns a fresh copy of the stored snapshot.
+func (m *MemoryStorage) LoadSnapshot(id int64) (*WorldSnapshot, error) {
+	m.mu.RLock()
+	s, ok := m.snapshots[id]
+	m.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("snapshot %d not found", id)
+	}
+	return DecodeSnapshot(s.data)
+}
+
+func (m *MemoryStorage) ListSnapshots() ([]SnapshotInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	infos := make([]SnapshotInfo, 0, len(m.snapshots))
+	for _, s := range m.snapshots {
+		infos = append(infos, s.info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos, nil
+}
+
+func (m *MemoryStorage) DeleteSnapshots(ids []int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, id := range ids {
+		delete(m.snapshots, id)
+	}
+	return nil
+}
+
+func (m *MemoryStorage) SaveStats(st StatsRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.stats = append(m.stats, st)
+	return nil
+}
+
+func (m *MemoryStorage) ListStats() ([]StatsRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return append([]StatsRecord(nil), m.stats...), nil
+}
+
+func (m *MemoryStorage) SaveEvents(events []world.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.events = append(m.events, events...)
+	return nil
+}
+
+func (m *MemoryStorage) ListEvents(fromTick, toTick int64) ([]world.Event, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var events []world.Event
+	for _, e := range m.events {
+		if e.Tick >= fromTick && e.Tick < toTick {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (m *MemoryStorage) SaveLineage(records []LineageRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, r := range records {
+		m.lineage[r.CreatureID] = r
+	}
+	return nil
+}
+
+func (m *MemoryStorage) Ancestors(creatureID int) ([]LineageRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return ancestors(m.lineage, creatureID), nil
+}
@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+// migration is one step of the SQLite schema history.
+// Migrations run in order, each in its own transaction, and are never edited
+// once released: add a new one instead.
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "snapshots",
+		sql: `
+		CREATE TABLE IF NOT EXISTS snapshots (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			data JSON
+		);`,
+	},
+	{
+		version: 2,
+		name:    "stats, events and lineage",
+		sql: `
+		CREATE TABLE IF NOT EXISTS stats (
+			tick INTEGER PRIMARY KEY,
+			timestamp INTEGER NOT NULL,
+			creatures INTEGER NOT NULL,
+			food INTEGER NOT NULL,
+			species INTEGER NOT NULL,
+			carnivores INTEGER NOT NULL,
+			max_generation INTEGER NOT NULL,
+			mean_energy REAL NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			tick INTEGER NOT NULL,
+			kind INTEGER NOT NULL,
+			creature_id INTEGER NOT NULL,
+			species_id INTEGER NOT NULL,
+			generation INTEGER NOT NULL,
+			parent_id INTEGER NOT NULL,
+			mate_id INTEGER NOT NULL,
+			x REAL NOT NULL,
+			y REAL NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS events_tick ON events (tick);
+		CREATE TABLE IF NOT EXISTS lineage (
+			creature_id INTEGER PRIMARY KEY,
+			parent_id INTEGER NOT NULL,
+			mate_id INTEGER NOT NULL,
+			species_id INTEGER NOT NULL,
+			generation INTEGER NOT NULL,
+			born_tick INTEGER NOT NULL
+		);`,
+	},
+	{
+		version: 3,
+		name:    "run identifiers",
+		sql: `
+		CREATE TABLE runs (
+			id TEXT PRIMARY KEY,
+			parent_run TEXT NOT NULL DEFAULT '',
+			parent_snapshot INTEGER NOT NULL DEFAULT 0,
+			overrides JSON,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		INSERT INTO runs (id) VALUES ('main');
+
+		ALTER TABLE snapshots ADD COLUMN run_id TEXT NOT NULL DEFAULT 'main';
+		CREATE INDEX snapshots_run ON snapshots (run_id, id);
+
+		ALTER TABLE events ADD COLUMN run_id TEXT NOT NULL DEFAULT 'main';
+		DROP INDEX events_tick;
+		CREATE INDEX events_run_tick ON events (run_id, tick);
+
+		CREATE TABLE stats_v3 (
+			run_id TEXT NOT NULL,
+			tick INTEGER NOT NULL,
+			timestamp INTEGER NOT NULL,
+			creatures INTEGER NOT NULL,
+			food INTEGER NOT NULL,
+			species INTEGER NOT NULL,
+			carnivores INTEGER NOT NULL,
+			max_generation INTEGER NOT NULL,
+			mean_energy REAL NOT NULL,
+			PRIMARY KEY (run_id, tick)
+		);
+		INSERT INTO stats_v3 SELECT 'main', tick, timestamp, creatures, food, species, carnivores, max_generation, mean_energy FROM stats;
+		DROP TABLE stats;
+		ALTER TABLE stats_v3 RENAME TO stats;
+
+		CREATE TABLE lineage_v3 (
+			run_id TEXT NOT NULL,
+			creature_id INTEGER NOT NULL,
+			parent_id INTEGER NOT NULL,
+			mate_id INTEGER NOT NULL,
+			species_id INTEGER NOT NULL,
+			generation INTEGER NOT NULL,
+			born_tick INTEGER NOT NULL,
+			PRIMARY KEY (run_id, creature_id)
+		);
+		INSERT INTO lineage_v3 SELECT 'main', creature_id, parent_id, mate_id, species_id, generation, born_tick FROM lineage;
+		DROP TABLE lineage;
+		ALTER TABLE lineage_v3 RENAME TO lineage;`,
+	},
+	{
+		version: 4,
+		name:    "linkage disequilibrium stats",
+		sql: `
+		ALTER TABLE stats ADD COLUMN linked_ld REAL NOT NULL DEFAULT 0;
+		ALTER TABLE stats ADD COLUMN unlinked_ld REAL NOT NULL DEFAULT 0;`,
+	},
+	{
+		version: 5,
+		name:    "mutation rate stats",
+		sql: `
+		ALTER TABLE stats ADD COLUMN mutation_rate REAL NOT NULL DEFAULT 0;
+		ALTER TABLE stats ADD COLUMN mutation_strength REAL NOT NULL DEFAULT 0;`,
+	},
+	{
+		version: 6,
+		name:    "runs recorded without a runs row",
+		sql: `
+		INSERT OR IGNORE INTO runs (id)
+			SELECT run_id FROM snapshots UNION SELECT run_id FROM stats
+			UNION SELECT run_id FROM events UNION SELECT run_id FROM lineage;`,
+	},
+}
+
+// LatestSchemaVersion is the schema version after all migrations ran.
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// SchemaVersion returns the version recorded in the database (0 if none).
+func SchemaVersion(db *sql.DB) (int, error) {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_version (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`); err != nil {
+		return 0, err
+	}
+
+	var version int
+	err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version)
+	return version, err
+}
+
+// migrate applies all pending migrations.
+func migrate(db *sql.DB) error {
+	current, err := SchemaVersion(db)
+	if err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(m.sql); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+		if _, err := tx.Exec("INSERT INTO schema_version (version, name) VALUES (?, ?)", m.version, m.name); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		log.Printf("Applied DB migration %d: %s", m.version, m.name)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"sort"
+	"time"
+)
+
+// RetentionPolicy thins out old snapshots in tiers:
+// one per hour while younger than Hourly, one per day while younger than Daily,
+// then one per week while younger than Weekly. A zero Weekly keeps weekly
+// snapshots forever. The newest snapshot is always kept.
+type RetentionPolicy struct {
+	Hourly time.Duration
+	Daily  time.Duration
+	Weekly time.Duration
+}
+
+// SnapshotInfo describes a stored snapshot without its payload.
+type SnapshotInfo struct {
+	ID        int64
+	CreatedAt time.Time
+	Size      int
+}
+
+// Expired returns the IDs of snapshots the policy no longer keeps.
+// Within each bucket the oldest snapshot survives, so the kept set is stable
+// as new snapshots arrive.
+func (p RetentionPolicy) Expired(snapshots []SnapshotInfo, now time.Time) []int64 {
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	sorted := make([]SnapshotInfo, len(snapshots))
+	copy(sorted, snapshots)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+	})
+	newest := sorted[len(sorted)-1].ID
+
+	type bucket struct {
+		tier int
+		key  int64
+	}
+	seen := make(map[bucket]bool)
+	var expired []int64
+
+	for _, s := range sorted {
+		if s.ID == newest {
+			continue
+		}
+		age := now.Sub(s.CreatedAt)
+
+		var b bucket
+		switch {
+		case age < p.Hourly:
+			b = bucket{tier: 0, key: s.CreatedAt.Truncate(time.Hour).Unix()}
+		case age < p.Daily:
+			b = bucket{tier: 1, key: s.CreatedAt.Truncate(24 * time.Hour).Unix()}
+		case p.Weekly == 0 || age < p.Weekly:
+			b = bucket{tier: 2, key: s.CreatedAt.Truncate(7 * 24 * time.Hour).Unix()}
+		default:
+			expired = append(expired, s.ID)
+			continue
+		}
+
+		if seen[b] {
+			expired = append(expired, s.ID)
+			continue
+		}
+		seen[b] = true
+	}
+
+	return expired
+}
@@ -3,66 +3,347 @@ package storage
 import (
 	"database/sql"
 	"encoding/json"
-	"log"
-	"time"
+	"fmt"
 
-	"evo-sim/internal/entity"
+	"evo-sim/internal/world"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
-type Storage struct {
-	DB *sql.DB
+// SQLiteStorage keeps the history of all runs in a single SQLite database.
+type SQLiteStorage struct {
+	DB     *sql.DB
+	Format string // Encoding for new snapshots: FormatJSON or FormatBinary
+	RunID  string
 }
 
-type WorldSnapshot struct {
-	Timestamp int64              `json:"timestamp"`
-	Stats     map[string]int     `json:"stats"` // Например: кол-во живых
-	Creatures []*entity.Creature `json:"creatures"`
-	Food      []entity.Food      `json:"food"`
+func NewSQLiteStorage(dbPath, format, run string) (*SQLiteStorage, error) {
+	if err := CheckFormat(format); err != nil {
+		return nil, err
+	}
+	if err := CheckRunID(run); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("open DB: %w", err)
+	}
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStorage{DB: db, Format: format, RunID: run}, nil
 }
 
-func NewStorage(dbPath string) *Storage {
-	db, err := sql.Open("sqlite3", dbPath)
+func (s *SQLiteStorage) Run() string {
+	return s.RunID
+}
+
+func (s *SQLiteStorage) SaveRun(run RunInfo) error {
+	if err := CheckRunID(run.ID); err != nil {
+		return err
+	}
+	overrides, err := json.Marshal(run.Overrides)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.Exec(`INSERT OR REPLACE INTO runs (id, parent_run, parent_snapshot, overrides) VALUES (?, ?, ?, ?)`,
+		run.ID, run.ParentRun, run.ParentSnapshot, overrides)
+	return err
+}
+
+func (s *SQLiteStorage) ListRuns() ([]RunInfo, error) {
+	rows, err := s.DB.Query("SELECT id, parent_run, parent_snapshot, overrides, created_at FROM runs ORDER BY created_at, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []RunInfo
+	for rows.Next() {
+		var run RunInfo
+		var overrides []byte
+		if err := rows.Scan(&run.ID, &run.ParentRun, &run.ParentSnapshot, &overrides, &run.CreatedAt); err != nil {
+			return nil, err
+		}
+		if len(overrides) > 0 {
+			if err := json.Unmarshal(overrides, &run.Overrides); err != nil {
+				return nil, err
+			}
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+func (s *SQLiteStorage) Close() error {
+	return s.DB.Close()
+}
+
+func (s *SQLiteStorage) SaveSnapshot(snapshot *WorldSnapshot) (int64, error) {
+	data, err := EncodeSnapshot(snapshot, s.Format)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := s.DB.Exec("INSERT INTO snapshots (run_id, data) VALUES (?, ?)", s.RunID, data)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (s *SQLiteStorage) ListSnapshots() ([]SnapshotInfo, error) {
+	rows, err := s.DB.Query("SELECT id, created_at, length(data) FROM snapshots WHERE run_id = ? ORDER BY id", s.RunID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var infos []SnapshotInfo
+	for rows.Next() {
+		var info SnapshotInfo
+		if err := rows.Scan(&info.ID, &info.CreatedAt, &info.Size); err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, rows.Err()
+}
+
+func (s *SQLiteStorage) LoadSnapshot(id int64) (*WorldSnapshot, error) {
+	var data []byte
+	if err := s.DB.QueryRow("SELECT data FROM snapshots WHERE id = ? AND run_id = ?", id, s.RunID).Scan(&data); err != nil {
+		return nil, err
+	}
+	return DecodeSnapshot(data)
+}
+
+func (s *SQLiteStorage) DeleteSnapshots(ids []int64) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if _, err := tx.Exec("DELETE FROM snapshots WHERE id = ? AND run_id = ?", id, s.RunID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStorage) SaveStats(st StatsRecord) error {
+	_, err := s.DB.Exec(`INSERT OR REPLACE INTO stats
+		(run_id, tick, timestamp, creatures, food, species, carnivores, max_generation, mean_energy, linked_ld, unlinked_ld, mutation_rate, mutation_strength)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		s.RunID, st.Tick, st.Timestamp, st.Creatures, st.Food, st.Species, st.Carnivores, st.MaxGeneration, st.MeanEnergy, st.LinkedLD, st.UnlinkedLD, st.MutationRate, st.MutationStrength)
+	return err
+}
+
+func (s *SQLiteStorage) ListStats() ([]StatsRecord, error) {
+	rows, err := s.DB.Query(`SELECT tick, timestamp, creatures, food, species, carnivores, max_generation, mean_energy, linked_ld, unlinked_ld, mutation_rate, mutation_strength
+		FROM stats WHERE run_id = ? ORDER BY tick`, s.RunID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []StatsRecord
+	for rows.Next() {
+		var st StatsRecord
+		if err := rows.Scan(&st.Tick, &st.Timestamp, &st.Creatures, &st.Food, &st.Species, &st.Carnivores, &st.MaxGeneration, &st.MeanEnergy, &st.LinkedLD, &st.UnlinkedLD, &st.MutationRate, &st.MutationStrength); err != nil {
+			return nil, err
+		}
+		stats = append(stats, st)
+	}
+	return stats, rows.Err()
+}
+
+func (s *SQLiteStorage) SaveEvents(events []world.Event) error {
+	tx, err := s.DB.Begin()
 	if err != nil {
-		log.Fatal("Failed to open DB:", err)
+		return err
+	}
+	stmt, err := tx.Prepare(`INSERT INTO events
+		(run_id, tick, kind, creature_id, species_id, generation, parent_id, mate_id, x, y)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, e := range events {
+		if _, err := stmt.Exec(s.RunID, e.Tick, e.Kind, e.CreatureID, e.SpeciesID, e.Generation, e.ParentID, e.MateID, e.X, e.Y); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStorage) ListEvents(fromTick, toTick int64) ([]world.Event, error) {
+	rows, err := s.DB.Query(`SELECT tick, kind, creature_id, species_id, generation, parent_id, mate_id, x, y
+		FROM events WHERE run_id = ? AND tick >= ? AND tick < ? ORDER BY id`, s.RunID, fromTick, toTick)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []world.Event
+	for rows.Next() {
+		var e world.Event
+		if err := rows.Scan(&e.Tick, &e.Kind, &e.CreatureID, &e.SpeciesID, &e.Generation, &e.ParentID, &e.MateID, &e.X, &e.Y); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
 	}
+	return events, rows.Err()
+}
 
-	query := `
-	CREATE TABLE IF NOT EXISTS snapshots (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		data JSON
-	);`
+func (s *SQLiteStorage) SaveLineage(records []LineageRecord) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	for _, r := range records {
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO lineage
+			(run_id, creature_id, parent_id, mate_id, species_id, generation, born_tick)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			s.RunID, r.CreatureID, r.ParentID, r.MateID, r.SpeciesID, r.Generation, r.BornTick); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
 
-	if _, err := db.Exec(query); err != nil {
-		log.Fatal("Failed to create table:", err)
+func (s *SQLiteStorage) Ancestors(creatureID int) ([]LineageRecord, error) {
+	rows, err := s.DB.Query(`
+		WITH RECURSIVE chain(creature_id, parent_id, mate_id, species_id, generation, born_tick, depth) AS (
+			SELECT creature_id, parent_id, mate_id, species_id, generation, born_tick, 0
+			FROM lineage WHERE run_id = ?1 AND creature_id = ?2
+			UNION ALL
+			SELECT l.creature_id, l.parent_id, l.mate_id, l.species_id, l.generation, l.born_tick, chain.depth + 1
+			FROM lineage l JOIN chain ON l.run_id = ?1 AND l.creature_id = chain.parent_id
+			WHERE chain.parent_id != 0 AND chain.depth < 100000
+		)
+		SELECT creature_id, parent_id, mate_id, species_id, generation, born_tick FROM chain ORDER BY depth`, s.RunID, creatureID)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	return &Storage{DB: db}
+	var chain []LineageRecord
+	for rows.Next() {
+		var r LineageRecord
+		if err := rows.Scan(&r.CreatureID, &r.ParentID, &r.MateID, &r.SpeciesID, &r.Generation, &r.BornTick); err != nil {
+			return nil, err
+		}
+		chain = append(chain, r)
+	}
+	return chain, rows.Err()
 }
 
-func (s *Storage) SaveSnapshot(creatures []*entity.Creature, food []entity.Food) {
-	snapshot := WorldSnapshot{
-		Timestamp: time.Now().Unix(),
-		Stats: map[string]int{
-			"creatures_count": len(creatures),
-			"food_count":      len(food),
-		},
-		Creatures: creatures,
-		Food:      food,
+// allSnapshots lists snapshots of every run, for maintenance tasks.
+func (s *SQLiteStorage) allSnapshots() ([]SnapshotInfo, error) {
+	rows, err := s.DB.Query("SELECT id, created_at, length(data) FROM snapshots ORDER BY id")
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
+
+	var infos []SnapshotInfo
+	for rows.Next() {
+		var info SnapshotInfo
+		if err := rows.Scan(&info.ID, &info.CreatedAt, &info.Size); err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, rows.Err()
+}
 
-	data, err := json.Marshal(snapshot)
+// Reencode rewrites every snapshot of every run stored in another encoding
+// using format.
+// Returns the number of rewritten snapshots.
+func (s *SQLiteStorage) Reencode(format string) (int, error) {
+	if err := CheckFormat(format); err != nil {
+		return 0, err
+	}
+	infos, err := s.allSnapshots()
 	if err != nil {
-		log.Println("Error marshalling snapshot:", err)
-		return
+		return 0, err
 	}
 
-	_, err = s.DB.Exec("INSERT INTO snapshots (data) VALUES (?)", data)
+	count := 0
+	for _, info := range infos {
+		var data []byte
+		if err := s.DB.QueryRow("SELECT data FROM snapshots WHERE id = ?", info.ID).Scan(&data); err != nil {
+			return count, err
+		}
+		if IsBinarySnapshot(data) == (format == FormatBinary) {
+			continue
+		}
+
+		snapshot, err := DecodeSnapshot(data)
+		if err != nil {
+			return count, err
+		}
+		encoded, err := EncodeSnapshot(snapshot, format)
+		if err != nil {
+			return count, err
+		}
+		if _, err := s.DB.Exec("UPDATE snapshots SET data = ? WHERE id = ?", encoded, info.ID); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// UpgradeSnapshots rewrites JSON snapshots of every run stored with an older
+// creature layout in the current layout. Returns the number of rewritten snapshots.
+func (s *SQLiteStorage) UpgradeSnapshots() (int, error) {
+	infos, err := s.allSnapshots()
 	if err != nil {
-		log.Println("Error saving to DB:", err)
-	} else {
-		log.Printf("Snapshot saved. Size: %d bytes", len(data))
+		return 0, err
 	}
+
+	count := 0
+	for _, info := range infos {
+		var data []byte
+		if err := s.DB.QueryRow("SELECT data FROM snapshots WHERE id = ?", info.ID).Scan(&data); err != nil {
+			return count, err
+		}
+		if IsBinarySnapshot(data) {
+			continue
+		}
+		version, err := snapshotVersion(data)
+		if err != nil {
+			return count, fmt.Errorf("snapshot %d: %w", info.ID, err)
+		}
+		if version >= CurrentSnapshotVersion {
+			continue
+		}
+
+		upgraded, err := upgradeSnapshotJSON(data, version)
+		if err != nil {
+			return count, fmt.Errorf("snapshot %d: %w", info.ID, err)
+		}
+		if _, err := s.DB.Exec("UPDATE snapshots SET data = ? WHERE id = ?", upgraded, info.ID); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Compact rebuilds the database file to reclaim space freed by deletes.
+func (s *SQLiteStorage) Compact() error {
+	_, err := s.DB.Exec("VACUUM")
+	return err
 }
@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"evo-sim/internal/entity"
+	"evo-sim/internal/world"
+)
+
+// Storage persists the history of one simulation run.
+type Storage interface {
+	Run() string
+	SaveRun(run RunInfo) error
+	ListRuns() ([]RunInfo, error)
+
+	SaveSnapshot(snapshot *WorldSnapshot) (int64, error)
+	LoadSnapshot(id int64) (*WorldSnapshot, error)
+	ListSnapshots() ([]SnapshotInfo, error) // Oldest first
+	DeleteSnapshots(ids []int64) error
+
+	SaveStats(stats StatsRecord) error
+	ListStats() ([]StatsRecord, error)
+
+	SaveEvents(events []world.Event) error
+	ListEvents(fromTick, toTick int64) ([]world.Event, error)
+
+	SaveLineage(records []LineageRecord) error
+	// Ancestors returns the recorded parent chain of a creature, starting with
+	// the creature itself.
+	Ancestors(creatureID int) ([]LineageRecord, error)
+
+	Close() error
+}
+
+// Storage backends selectable with Open.
+const (
+	BackendSQLite = "sqlite"
+	BackendDir    = "dir"
+	BackendMemory = "memory"
+)
+
+// DefaultRun is the run used when no run ID is given. Histories recorded
+// before runs existed belong to it.
+const DefaultRun = "main"
+
+// runIDPattern keeps run IDs usable as a single path element: letters,
+// digits, '.', '_' and '-', starting with a letter or digit.
+var runIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// CheckRunID reports whether id is a valid run ID. Every backend checks the
+// runs it is opened for or records.
+func CheckRunID(id string) error {
+	if !runIDPattern.MatchString(id) {
+		return fmt.Errorf("invalid run ID %q: use up to 64 letters, digits, '.', '_' or '-', starting with a letter or digit", id)
+	}
+	return nil
+}
+
+// RunInfo describes a run. Forked runs record where they branched off and
+// which config values they changed.
+type RunInfo struct {
+	ID             string            `json:"id"`
+	ParentRun      string            `json:"parentRun,omitempty"`
+	ParentSnapshot int64             `json:"parentSnapshot,omitempty"`
+	Overrides      map[string]string `json:"overrides,omitempty"`
+	CreatedAt      time.Time         `json:"createdAt"`
+}
+
+type WorldSnapshot struct {
+	Version   int                `json:"version"` // Creature layout, see CurrentSnapshotVersion
+	Timestamp int64              `json:"timestamp"`
+	Tick      int64              `json:"tick,omitempty"`
+	Stats     map[string]int     `json:"stats"` // Например: кол-во живых
+	Creatures []*entity.Creature `json:"creatures"`
+	Food      []entity.Food      `json:"food"`
+	Terrain   *world.TerrainGrid `json:"terrain,omitempty"` // Missing in snapshots taken before forking existed
+}
+
+// NewSnapshot captures the given population with basic stats.
+func NewSnapshot(creatures []*entity.Creature, food []entity.Food) *WorldSnapshot {
+	return &WorldSnapshot{
+		Version:   CurrentSnapshotVersion,
+		Timestamp: time.Now().Unix(),
+		Stats: map[string]int{
+			"creatures_count": len(creatures),
+			"food_count":      len(food),
+		},
+		Creatures: creatures,
+		Food:      food,
+	}
+}
+
+// CaptureWorld snapshots the full world state, including terrain, so it can
+// be restored or forked later. The caller must hold w.Mu.
+func CaptureWorld(w *world.World) *WorldSnapshot {
+	snapshot := NewSnapshot(w.Creatures, w.Food)
+	snapshot.Tick = w.Tick
+	snapshot.Terrain = w.Terrain
+	return snapshot
+}
+
+// StatsRecord is a periodic population summary.
+type StatsRecord struct {
+	Tick          int64   `json:"tick"`
+	Timestamp     int64   `json:"timestamp"`
+	Creatures     int     `json:"creatures"`
+	Food          int     `json:"food"`
+	Species       int     `json:"species"`
+	Carnivores    int     `json:"carnivores"`
+	MaxGeneration int     `json:"maxGeneration"`
+	MeanEnergy    float64 `json:"meanEnergy"`
+	LinkedLD      float64 `json:"linkedLD"`   // Mean r² of loci pairs on one chromosome
+	UnlinkedLD    float64 `json:"unlinkedLD"` // Mean r² of loci pairs on different chromosomes
+
+	// Mean mutation rate and strength offspring get; they evolve with
+	// ADAPTIVE_MUTATION
+	MutationRate     float64 `json:"mutationRate"`
+	MutationStrength float64 `json:"mutationStrength"`
+}
+
+// LineageRecord links a creature to its parents.
+type LineageRecord struct {
+	CreatureID int   `json:"creatureId"`
+	ParentID   int   `json:"parentId,omitempty"`
+	MateID     int   `json:"mateId,omitempty"`
+	SpeciesID  int   `json:"speciesId"`
+	Generation int   `json:"generation"`
+	BornTick   int64 `json:"bornTick"`
+}
+
+// LineageFromEvents extracts lineage records from birth events.
+func LineageFromEvents(events []world.Event) []LineageRecord {
+	var records []LineageRecord
+	for _, e := range events {
+		if e.Kind != world.EventBirth {
+			continue
+		}
+		records = append(records, LineageRecord{
+			CreatureID: e.CreatureID,
+			ParentID:   e.ParentID,
+			MateID:     e.MateID,
+			SpeciesID:  e.SpeciesID,
+			Generation: e.Generation,
+			BornTick:   e.Tick,
+		})
+	}
+	return records
+}
+
+// Open creates a storage backend bound to a run (DefaultRun if empty).
+// For BackendDir, path is a directory; BackendMemory ignores it.
+func Open(backend, path, format, run string) (Storage, error) {
+	if run == "" {
+		run = DefaultRun
+	}
+	if err := CheckFormat(format); err != nil {
+		return nil, err
+	}
+	if err := CheckRunID(run); err != nil {
+		return nil, err
+	}
+	switch backend {
+	case BackendSQLite, "":
+		return NewSQLiteStorage(path, format, run)
+	case BackendDir:
+		return NewDirStorage(path, format, run)
+	case BackendMemory:
+		return NewMemoryStorage(run), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", backend)
+	}
+}
+
+// Prune deletes snapshots that the retention policy no longer keeps.
+// Returns the number of deleted snapshots.
+func Prune(s Storage, policy RetentionPolicy) (int, error) {
+	infos, err := s.ListSnapshots()
+	if err != nil {
+		return 0, err
+	}
+
+	expired := policy.Expired(infos, time.Now())
+	if len(expired) == 0 {
+		return 0, nil
+	}
+	return len(expired), s.DeleteSnapshots(expired)
+}
+
+// ancestors walks parent links in a lineage index.
+func ancestors(index map[int]LineageRecord, creatureID int) []LineageRecord {
+	var chain []LineageRecord
+	seen := make(map[int]bool)
+	for id := creatureID; id != 0 && !seen[id]; {
+		rec, ok := index[id]
+		if !ok {
+			break
+		}
+		seen[id] = true
+		chain = append(chain, rec)
+		id = rec.ParentID
+	}
+	return chain
+}
@@ -0,0 +1,292 @@
+package storage
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"evo-sim/internal/brain"
+	"evo-sim/internal/entity"
+	"evo-sim/internal/world"
+)
+
+func TestBackends(t *testing.T) {
+	backends := map[string]func(t *testing.T) Storage{
+		BackendMemory: func(t *testing.T) Storage { return NewMemoryStorage(DefaultRun) },
+		BackendSQLite: func(t *testing.T) Storage {
+			s, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "test.db"), FormatBinary, DefaultRun)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return s
+		},
+		BackendDir: func(t *testing.T) Storage {
+			s, err := NewDirStorage(t.TempDir(), FormatJSON, DefaultRun)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return s
+		},
+	}
+
+	for name, open := range backends {
+		t.Run(name, func(t *testing.T) {
+			s := open(t)
+			defer s.Close()
+			testStorage(t, s)
+		})
+	}
+}
+
+func testStorage(t *testing.T, s Storage) {
+	creatures := []*entity.Creature{entity.NewCreature(42, 1, 2, brain.Spec{Type: brain.TypeElman}, 11, 2, 0.005, 0)}
+	food := []entity.Food{{ID: 7, X: 3, Y: 4}}
+
+	id1, err := s.SaveSnapshot(NewSnapshot(creatures, food))
+	if err != nil {
+		t.Fatalf("SaveSnapshot: %v", err)
+	}
+	id2, err := s.SaveSnapshot(NewSnapshot(nil, food))
+	if err != nil {
+		t.Fatalf("SaveSnapshot: %v", err)
+	}
+
+	infos, err := s.ListSnapshots()
+	if err != nil || len(infos) != 2 || infos[0].ID != id1 || infos[1].ID != id2 {
+		t.Fatalf("ListSnapshots: got %+v, %v", infos, err)
+	}
+
+	loaded, err := s.LoadSnapshot(id1)
+	if err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+	if len(loaded.Creatures) != 1 || loaded.Creatures[0].ID != 42 || loaded.Stats["food_count"] != 1 {
+		t.Errorf("Loaded snapshot mismatch: %+v", loaded)
+	}
+	loaded.Creatures[0].ID = 99
+	if again, err := s.LoadSnapshot(id1); err != nil || again.Creatures[0].ID != 42 {
+		t.Errorf("Changing a loaded snapshot altered the stored one")
+	}
+
+	if err := s.DeleteSnapshots([]int64{id1}); err != nil {
+		t.Fatalf("DeleteSnapshots: %v", err)
+	}
+	if infos, _ := s.ListSnapshots(); len(infos) != 1 {
+		t.Errorf("Expected 1 snapshot after delete, got %d", len(infos))
+	}
+
+	if err := s.SaveStats(StatsRecord{Tick: 60, Creatures: 10, Species: 3, LinkedLD: 0.25, MutationRate: 0.05}); err != nil {
+		t.Fatalf("SaveStats: %v", err)
+	}
+	if stats, err := s.ListStats(); err != nil || len(stats) != 1 || stats[0].Species != 3 || stats[0].LinkedLD != 0.25 || stats[0].MutationRate != 0.05 {
+		t.Errorf("ListStats: got %+v, %v", stats, err)
+	}
+
+	events := []world.Event{
+		{Tick: 1, Kind: world.EventBirth, CreatureID: 1, Generation: 1},
+		{Tick: 5, Kind: world.EventBirth, CreatureID: 2, ParentID: 1, Generation: 2},
+		{Tick: 9, Kind: world.EventBirth, CreatureID: 3, ParentID: 2, MateID: 4, Generation: 3},
+		{Tick: 12, Kind: world.EventDeath, CreatureID: 1},
+	}
+	if err := s.SaveEvents(events); err != nil {
+		t.Fatalf("SaveEvents: %v", err)
+	}
+	if got, err := s.ListEvents(5, 12); err != nil || len(got) != 2 || got[1].MateID != 4 {
+		t.Errorf("ListEvents: got %+v, %v", got, err)
+	}
+
+	if err := s.SaveLineage(LineageFromEvents(events)); err != nil {
+		t.Fatalf("SaveLineage: %v", err)
+	}
+	chain, err := s.Ancestors(3)
+	if err != nil {
+		t.Fatalf("Ancestors: %v", err)
+	}
+	if len(chain) != 3 || chain[0].CreatureID != 3 || chain[2].CreatureID != 1 {
+		t.Errorf("Ancestors: got %+v", chain)
+	}
+}
+
+func TestSQLiteStorage_MigratesAndUpgradesLegacySnapshots(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+
+	// A database created before migrations existed, holding a haploid snapshot
+	legacy, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = legacy.Exec(`CREATE TABLE snapshots (id INTEGER PRIMARY KEY AUTOINCREMENT, created_at DATETIME DEFAULT CURRENT_TIMESTAMP, data JSON);
+		INSERT INTO snapshots (data) VALUES ('{"timestamp":1,"stats":{},"creatures":[{"ID":5,"Genome":{"SizeGene":2.5,"Diet":0.9,"ColorR":0.5},"Brain":{"InputSize":11,"HiddenSize":4,"OutputSize":2}}],"food":[]}');`)
+	legacy.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewSQLiteStorage(path, FormatJSON, DefaultRun)
+	if err != nil {
+		t.Fatalf("Open legacy DB: %v", err)
+	}
+	defer s.Close()
+
+	if v, err := SchemaVersion(s.DB); err != nil || v != LatestSchemaVersion() {
+		t.Errorf("Schema version: got %d (%v), want %d", v, err, LatestSchemaVersion())
+	}
+
+	n, err := s.UpgradeSnapshots()
+	if err != nil || n != 1 {
+		t.Fatalf("UpgradeSnapshots: got %d, %v", n, err)
+	}
+
+	snapshot, err := s.LoadSnapshot(1)
+	if err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+	g := snapshot.Creatures[0].Genome
+	if snapshot.Version != CurrentSnapshotVersion {
+		t.Errorf("Version: got %d, want %d", snapshot.Version, CurrentSnapshotVersion)
+	}
+	if g.Alleles[entity.LocusSize] != [2]float64{2.5, 2.5} || g.Alleles[entity.LocusDiet][0] != 0.9 || g.Alleles[entity.LocusSense][0] != 100.0 || g.Express(entity.LocusColorR) != 0.5 {
+		t.Errorf("Upgraded genome mismatch: %+v", g)
+	}
+	if _, hidden, _ := snapshot.Creatures[0].Brain.Shape(); hidden != 4 {
+		t.Errorf("Brain shape lost: %+v", snapshot.Creatures[0].Brain)
+	}
+}
+
+func TestRuns_KeepHistoriesSeparate(t *testing.T) {
+	for _, backend := range []string{BackendSQLite, BackendDir} {
+		t.Run(backend, func(t *testing.T) {
+			path := t.TempDir()
+			if backend == BackendSQLite {
+				path = filepath.Join(path, "runs.db")
+			}
+
+			main, err := Open(backend, path, FormatBinary, DefaultRun)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer main.Close()
+			fork, err := Open(backend, path, FormatBinary, "double-mutation")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer fork.Close()
+
+			mainID, _ := main.SaveSnapshot(NewSnapshot(nil, []entity.Food{{ID: 1}}))
+			forkID, _ := fork.SaveSnapshot(NewSnapshot(nil, nil))
+			fork.SaveStats(StatsRecord{Tick: 1})
+			if err := fork.SaveRun(RunInfo{ID: "double-mutation", ParentRun: DefaultRun, ParentSnapshot: mainID, Overrides: map[string]string{"MUTATION_RATE": "0.2"}}); err != nil {
+				t.Fatalf("SaveRun: %v", err)
+			}
+
+			if infos, _ := main.ListSnapshots(); len(infos) != 1 {
+				t.Errorf("Main run sees %d snapshots, want 1", len(infos))
+			}
+			if stats, _ := main.ListStats(); len(stats) != 0 {
+				t.Errorf("Main run sees fork stats: %+v", stats)
+			}
+
+			// Snapshot IDs may repeat across runs; each run loads only its own
+			for _, id := range []int64{mainID, forkID} {
+				if s, err := main.LoadSnapshot(id); err == nil && len(s.Food) != 1 {
+					t.Errorf("Main run loaded snapshot %d of the fork", id)
+				}
+				if s, err := fork.LoadSnapshot(id); err == nil && len(s.Food) != 0 {
+					t.Errorf("Fork loaded snapshot %d of the main run", id)
+				}
+			}
+			if _, err := fork.LoadSnapshot(forkID); err != nil {
+				t.Errorf("Fork can't load its own snapshot: %v", err)
+			}
+
+			runs, err := main.ListRuns()
+			if err != nil {
+				t.Fatalf("ListRuns: %v", err)
+			}
+			var found bool
+			for _, r := range runs {
+				if r.ID == "double-mutation" {
+					found = r.ParentSnapshot == mainID && r.Overrides["MUTATION_RATE"] == "0.2"
+				}
+			}
+			if !found {
+				t.Errorf("Fork not recorded correctly: %+v", runs)
+			}
+		})
+	}
+}
+
+func TestFormats_RejectUnknown(t *testing.T) {
+	path := t.TempDir()
+	for _, backend := range []string{BackendSQLite, BackendDir, BackendMemory} {
+		if _, err := Open(backend, filepath.Join(path, backend), "yaml", DefaultRun); err == nil {
+			t.Errorf("%s backend accepted snapshot format yaml", backend)
+		}
+	}
+
+	s, err := NewSQLiteStorage(filepath.Join(path, "reencode.db"), FormatJSON, DefaultRun)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.SaveSnapshot(NewSnapshot(nil, nil))
+	if n, err := s.Reencode("yaml"); err == nil {
+		t.Errorf("Reencode to yaml rewrote %d snapshots without an error", n)
+	}
+}
+
+func TestRuns_RejectUnsafeIDs(t *testing.T) {
+	root := t.TempDir()
+	for _, id := range []string{"../x", "a/b", ".hidden", "-flag", "runs/../../etc"} {
+		for _, backend := range []string{BackendSQLite, BackendDir, BackendMemory} {
+			if s, err := Open(backend, filepath.Join(root, "store"), FormatJSON, id); err == nil {
+				s.Close()
+				t.Errorf("%s backend opened run %q", backend, id)
+			}
+		}
+		if err := NewMemoryStorage(DefaultRun).SaveRun(RunInfo{ID: id}); err == nil {
+			t.Errorf("SaveRun recorded run %q", id)
+		}
+	}
+	if matches, _ := filepath.Glob(filepath.Join(root, "*")); len(matches) != 0 {
+		t.Errorf("Rejected runs left files behind: %v", matches)
+	}
+
+	s, err := Open(BackendDir, root, FormatJSON, "fork-2.b_1")
+	if err != nil {
+		t.Fatalf("Open safe run ID: %v", err)
+	}
+	s.Close()
+}
+
+func TestSQLiteStorage_RecordsRunsWithoutARunsRow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runs.db")
+
+	// A database at schema 5 whose plain run "solo" never got a runs row
+	s, err := NewSQLiteStorage(path, FormatJSON, "solo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SaveSnapshot(NewSnapshot(nil, nil))
+	if _, err := s.DB.Exec("DELETE FROM runs WHERE id = 'solo'; DELETE FROM schema_version WHERE version > 5"); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	s, err = NewSQLiteStorage(path, FormatJSON, DefaultRun)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	runs, err := s.ListRuns()
+	if err != nil {
+		t.Fatalf("ListRuns: %v", err)
+	}
+	var found bool
+	for _, r := range runs {
+		found = found || r.ID == "solo"
+	}
+	if !found {
+		t.Errorf("Run solo not recorded: %+v", runs)
+	}
+}
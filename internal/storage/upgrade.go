@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CurrentSnapshotVersion is the creature layout written by NewSnapshot.
+// Version 0 is any JSON snapshot without a "version" field.
+const CurrentSnapshotVersion = 2
+
+// snapshotUpgrades[v] turns a decoded JSON snapshot of version v into v+1.
+var snapshotUpgrades = []func(snapshot map[string]any) error{
+	upgradeV0DiploidGenome,
+	upgradeV1LocusGenome,
+}
+
+func snapshotVersion(data []byte) (int, error) {
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return 0, err
+	}
+	return header.Version, nil
+}
+
+// upgradeSnapshotJSON applies all upgrades from version to the current layout.
+func upgradeSnapshotJSON(data []byte, version int) ([]byte, error) {
+	if version > CurrentSnapshotVersion {
+		return nil, fmt.Errorf("snapshot version %d is newer than supported %d", version, CurrentSnapshotVersion)
+	}
+
+	var snapshot map[string]any
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+	for v := version; v < CurrentSnapshotVersion; v++ {
+		if err := snapshotUpgrades[v](snapshot); err != nil {
+			return nil, fmt.Errorf("upgrade v%d -> v%d: %w", v, v+1, err)
+		}
+	}
+	snapshot["version"] = CurrentSnapshotVersion
+	return json.Marshal(snapshot)
+}
+
+// eachCreature calls fn for every creature object in a decoded snapshot.
+func eachCreature(snapshot map[string]any, fn func(creature map[string]any) error) error {
+	creatures, _ := snapshot["creatures"].([]any)
+	for i, raw := range creatures {
+		creature, ok := raw.(map[string]any)
+		if !ok {
+			return fmt.Errorf("creature %d is not an object", i)
+		}
+		if err := fn(creature); err != nil {
+			return fmt.Errorf("creature %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// legacyGeneDefaults are the population means used by NewRandomGenome,
+// assigned to traits that a snapshot does not record at all.
+var legacyGeneDefaults = []struct {
+	trait string
+	value float64
+}{
+	{"Size", 1.0},
+	{"Speed", 1.0},
+	{"Sense", 100.0},
+	{"Diet", 0.5},
+	{"Metabolism", 1.0},
+	{"Fertility", 0.7},
+	{"Constitution", 1.0},
+	{"Hidden", 6.0},
+}
+
+// upgradeV0DiploidGenome converts haploid genomes ("SizeGene" or "Size")
+// into homozygous diploid allele pairs and fills traits that did not exist yet.
+func upgradeV0DiploidGenome(snapshot map[string]any) error {
+	return eachCreature(snapshot, func(creature map[string]any) error {
+		genome, ok := creature["Genome"].(map[string]any)
+		if !ok {
+			genome = make(map[string]any)
+			creature["Genome"] = genome
+		}
+
+		for _, gene := range legacyGeneDefaults {
+			a1, a2 := gene.trait+"Allele1", gene.trait+"Allele2"
+			if _, ok := genome[a1]; ok {
+				continue
+			}
+
+			value := gene.value
+			for _, legacy := range []string{gene.trait + "Gene", gene.trait} {
+				if v, ok := genome[legacy].(float64); ok {
+					value = v
+					delete(genome, legacy)
+					break
+				}
+			}
+			genome[a1] = value
+			genome[a2] = value
+		}
+		return nil
+	})
+}
+
+// upgradeV1LocusGenome moves genome fields into alleles keyed by locus name.
+// Fields it doesn't find are left for the loci's defaults.
+func upgradeV1LocusGenome(snapshot map[string]any) error {
+	diploid := map[string]string{
+		"Size": "size", "Speed": "speed", "Sense": "sense", "Diet": "diet",
+		"Metabolism": "metabolism", "Fertility": "fertility", "Constitution": "constitution",
+		"Hidden": "hidden", "FOV": "fov",
+	}
+	haploid := map[string]string{"ColorR": "color_r", "ColorG": "color_g", "ColorB": "color_b"}
+
+	return eachCreature(snapshot, func(creature map[string]any) error {
+		genome, ok := creature["Genome"].(map[string]any)
+		if !ok {
+			return nil
+		}
+		alleles := map[string]any{}
+		for field, locus := range diploid {
+			a1, ok1 := genome[field+"Allele1"]
+			a2, ok2 := genome[field+"Allele2"]
+			if ok1 && ok2 {
+				alleles[locus] = []any{a1, a2}
+			}
+			delete(genome, field+"Allele1")
+			delete(genome, field+"Allele2")
+		}
+		for field, locus := range haploid {
+			if v, ok := genome[field]; ok {
+				alleles[locus] = []any{v, v}
+			}
+			delete(genome, field)
+		}
+		if layers, ok := genome["LayerAlleles"].([]any); ok {
+			for k, pair := range layers {
+				alleles[fmt.Sprintf("layer%d", k+2)] = pair
+			}
+		}
+		delete(genome, "LayerAlleles")
+		genome["Alleles"] = alleles
+		return nil
+	})
+}
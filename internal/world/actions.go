@@ -0,0 +1,97 @@
+package world
+
+import (
+	"fmt"
+	"strings"
+
+	"evo-sim/internal/entity"
+)
+
+// Actions brains can be given outputs for. Without an output the engine does
+// them on its own: eats, hunts and mates whenever in range and lays a constant
+// pheromone trail. It never flees.
+const (
+	ActionEat    = "eat"
+	ActionAttack = "attack"
+	ActionMate   = "mate"
+	ActionFlee   = "flee"   // A burst of speed along the heading
+	ActionSignal = "signal" // Deposit pheromone
+)
+
+var actionNames = []string{ActionEat, ActionAttack, ActionMate, ActionFlee, ActionSignal}
+
+// ActionSet is a selection of action outputs, in output order after the
+// motors.
+type ActionSet struct {
+	names []string
+	index map[string]int
+}
+
+// NewActionSet selects actions by name. An empty selection leaves every
+// action to the engine.
+func NewActionSet(names []string) (*ActionSet, error) {
+	set := &ActionSet{index: map[string]int{}}
+	for _, name := range names {
+		known := false
+		for _, n := range actionNames {
+			known = known || n == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown action %q (known: %s)", name, strings.Join(actionNames, ", "))
+		}
+		if _, ok := set.index[name]; ok {
+			return nil, fmt.Errorf("action %q selected twice", name)
+		}
+		set.index[name] = len(set.names)
+		set.names = append(set.names, name)
+	}
+	return set, nil
+}
+
+// Names lists the selected actions in output order.
+func (a *ActionSet) Names() []string {
+	return a.names
+}
+
+// Has reports whether the brain decides on the action.
+func (a *ActionSet) Has(name string) bool {
+	_, ok := a.index[name]
+	return ok
+}
+
+// wants reports whether a creature tries an action this tick, and charges it
+// for trying. Actions the brain has no output for are left to the engine.
+func (w *World) wants(c *entity.Creature, output []float64, name string) bool {
+	i, ok := w.Actions.index[name]
+	if !ok {
+		return name != ActionFlee
+	}
+	if output[len(w.Motors.Names())+i] <= w.Cfg.ActionThreshold {
+		return false
+	}
+	c.Energy -= w.actionCost(name)
+	return true
+}
+
+func (w *World) actionCost(name string) float64 {
+	switch name {
+	case ActionEat:
+		return w.Cfg.EatCost
+	case ActionAttack:
+		return w.Cfg.AttackCost
+	case ActionMate:
+		return w.Cfg.MateCost
+	case ActionFlee:
+		return w.Cfg.FleeCost
+	default:
+		return w.Cfg.SignalCost
+	}
+}
+
+func mustActionSet(names []string) *ActionSet {
+	set, err := NewActionSet(names)
+	if err != nil {
+		panic(err) // Callers validate ACTIONS first
+	}
+	return set
+}
@@ -0,0 +1,94 @@
+package world
+
+import "math"
+
+// terrainTypes lists the terrain types in sensor order.
+var terrainTypes = []TerrainType{Water, Sand, Grass}
+
+// level ranks terrain types from low to high ground, for the gradient sensor.
+func (tt TerrainType) level() float64 {
+	switch tt {
+	case Water:
+		return 0
+	case Sand:
+		return 0.5
+	default:
+		return 1
+	}
+}
+
+// Nearest returns the centre of the closest cell of a terrain type and its
+// distance, or ok false if the map has none. Distances are exact to within a
+// cell.
+func (t *TerrainGrid) Nearest(worldX, worldY float64, tt TerrainType) (x, y, dist float64, ok bool) {
+	if t.nearest == nil {
+		t.buildNearest()
+	}
+	gx, gy := t.clamp(worldX, worldY)
+	src := t.nearest[tt][gy*t.Width+gx]
+	if src < 0 {
+		return 0, 0, 0, false
+	}
+	if int(src) == gy*t.Width+gx {
+		return worldX, worldY, 0, true
+	}
+	x = (float64(int(src)%t.Width) + 0.5) * t.Scale
+	y = (float64(int(src)/t.Width) + 0.5) * t.Scale
+	return x, y, math.Hypot(x-worldX, y-worldY), true
+}
+
+func (t *TerrainGrid) clamp(worldX, worldY float64) (gx, gy int) {
+	gx = min(max(int(worldX/t.Scale), 0), t.Width-1)
+	gy = min(max(int(worldY/t.Scale), 0), t.Height-1)
+	return gx, gy
+}
+
+// buildNearest finds, for every cell and terrain type, the closest cell of
+// that type. Each type's cells seed a flood fill that hands their position on
+// to neighbours while it is closer than what they have.
+func (t *TerrainGrid) buildNearest() {
+	t.nearest = make(map[TerrainType][]int32, len(terrainTypes))
+	n := t.Width * t.Height
+	dist := make([]float64, n)
+	var queue []int32
+
+	for _, tt := range terrainTypes {
+		src := make([]int32, n)
+		queue = queue[:0]
+		for i := range src {
+			src[i], dist[i] = -1, math.Inf(1)
+			if t.Cells[i] == tt {
+				src[i], dist[i] = int32(i), 0
+				queue = append(queue, int32(i))
+			}
+		}
+
+		for head := 0; head < len(queue); head++ {
+			i := int(queue[head])
+			x, y := i%t.Width, i/t.Width
+			sx, sy := int(src[i])%t.Width, int(src[i])/t.Width
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					nx, ny := x+dx, y+dy
+					if nx < 0 || ny < 0 || nx >= t.Width || ny >= t.Height {
+						continue
+					}
+					j := ny*t.Width + nx
+					if d := math.Hypot(float64(nx-sx), float64(ny-sy)); d < dist[j] {
+						src[j], dist[j] = src[i], d
+						queue = append(queue, int32(j))
+					}
+				}
+			}
+		}
+		t.nearest[tt] = src
+	}
+}
+
+// Gradient returns the slope of the ground across one cell in each
+// direction, in [-1, 1], pointing uphill from water to grass.
+func (t *TerrainGrid) Gradient(worldX, worldY float64) (gx, gy float64) {
+	gx = t.GetType(worldX+t.Scale, worldY).level() - t.GetType(worldX-t.Scale, worldY).level()
+	gy = t.GetType(worldX, worldY+t.Scale).level() - t.GetType(worldX, worldY-t.Scale).level()
+	return gx, gy
+}
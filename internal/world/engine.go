@@ -6,12 +6,18 @@ import (
 	"sync"
 	"time"
 
+	"evo-sim/internal/brain"
 	"evo-sim/internal/config"
 	"evo-sim/internal/entity"
 )
 
 type World struct {
 	Cfg            *config.Config
+	Sensors        *SensorSet // Brain inputs, from Cfg.Sensors
+	Motors         entity.Motors
+	Meiosis        entity.Meiosis
+	Mutation       entity.Mutation
+	Actions        *ActionSet // Action outputs after the motors, from Cfg.Actions
 	Creatures      []*entity.Creature
 	Food           []entity.Food
 	Grid           *Grid
@@ -23,11 +29,27 @@ type World struct {
 
 	// Control Logic
 	FoodSpawnAccumulator float64
+
+	Tick   int64   // Number of completed Update calls
+	events []Event // Pending events, see DrainEvents
+
+	// Reused by think when BatchBrains is set
+	batch    *brain.Batch
+	percepts []perception
+
+	// Reused by castRays
+	rayCreatures []*entity.Creature
+	rayFood      []entity.Food
 }
 
 func NewWorld(cfg *config.Config) *World {
 	w := &World{
 		Cfg:                  cfg,
+		Sensors:              mustSensorSet(cfg),
+		Motors:               motors(cfg),
+		Meiosis:              meiosis(cfg),
+		Mutation:             mutation(cfg),
+		Actions:              mustActionSet(cfg.Actions),
 		Grid:                 NewGrid(cfg.WorldWidth, cfg.WorldHeight, 40.0),
 		Terrain:              NewTerrainGrid(cfg.WorldWidth, cfg.WorldHeight, 20.0),
 		Pheromone:            NewPheromoneGrid(cfg.WorldWidth, cfg.WorldHeight, 20.0),
@@ -36,7 +58,7 @@ func NewWorld(cfg *config.Config) *World {
 		FoodSpawnAccumulator: 0.0,
 	}
 
-	w.spawnRandomCreatures(cfg.InitialPop)
+	w.spawnSeeds(cfg.InitialPop)
 	for i := 0; i < cfg.FoodCount; i++ {
 		w.spawnFood()
 	}
@@ -44,26 +66,82 @@ func NewWorld(cfg *config.Config) *World {
 	return w
 }
 
+// NewWorldFromState rebuilds a world from saved state, e.g. to fork a run from
+// a snapshot. A nil terrain is generated afresh. Species IDs of the creatures
+// are kept; each species' first creature becomes its centroid.
+func NewWorldFromState(cfg *config.Config, terrain *TerrainGrid, creatures []*entity.Creature, food []entity.Food, tick int64) *World {
+	if terrain == nil {
+		terrain = NewTerrainGrid(cfg.WorldWidth, cfg.WorldHeight, 20.0)
+	}
+
+	w := &World{
+		Cfg:            cfg,
+		Sensors:        mustSensorSet(cfg),
+		Motors:         motors(cfg),
+		Meiosis:        meiosis(cfg),
+		Mutation:       mutation(cfg),
+		Actions:        mustActionSet(cfg.Actions),
+		Creatures:      creatures,
+		Food:           food,
+		Grid:           NewGrid(cfg.WorldWidth, cfg.WorldHeight, 40.0),
+		Terrain:        terrain,
+		Pheromone:      NewPheromoneGrid(cfg.WorldWidth, cfg.WorldHeight, 20.0),
+		SpeciesManager: NewSpeciesManager(cfg.SpeciationThreshold),
+		StartTime:      time.Now(),
+		Tick:           tick,
+	}
+
+	for _, c := range creatures {
+		w.SpeciesManager.Register(c.SpeciesID, c.Genome)
+		brain.Adopt(c.Brain)
+	}
+
+	return w
+}
+
 func (w *World) spawnRandomCreatures(count int) {
 	for i := 0; i < count; i++ {
 		// Try to spawn on land
-		for attempt := 0; attempt < 5; attempt++ {
-			x := rand.Float64() * w.Cfg.WorldWidth
-			y := rand.Float64() * w.Cfg.WorldHeight
-			if w.Terrain.GetType(x, y) != Water {
-				c := entity.NewCreature(
-					rand.IntN(10000000),
-					x, y,
-					w.Cfg.InputSize,
-					w.Cfg.OutputSize,
-					w.Cfg.BrainCostPerNeuron,
-				)
-				c.SpeciesID = w.SpeciesManager.Classify(c.Genome)
-				w.Creatures = append(w.Creatures, c)
-				break
-			}
+		x, y, ok := w.landSpot(5)
+		if !ok {
+			continue
+		}
+		c := entity.NewCreature(
+			rand.IntN(10000000),
+			x, y,
+			w.brainSpec(),
+			w.Sensors.Size(),
+			len(w.OutputNames()),
+			w.Cfg.BrainCostPerNeuron,
+			w.visionCost(),
+		)
+		c.Genome.Sensors = w.Sensors.Key()
+		w.addFounder(c)
+	}
+}
+
+// landSpot picks random spots until one is on land, giving up after attempts
+// with the last one and ok false.
+func (w *World) landSpot(attempts int) (x, y float64, ok bool) {
+	for attempt := 0; attempt < attempts; attempt++ {
+		x = rand.Float64() * w.Cfg.WorldWidth
+		y = rand.Float64() * w.Cfg.WorldHeight
+		if w.Terrain.GetType(x, y) != Water {
+			return x, y, true
 		}
 	}
+	return x, y, false
+}
+
+// addFounder adds a creature without parents and records its birth.
+func (w *World) addFounder(c *entity.Creature) {
+	c.SpeciesID = w.SpeciesManager.Classify(c.Genome)
+	w.Creatures = append(w.Creatures, c)
+	w.recordEvent(Event{Kind: EventBirth, CreatureID: c.ID, SpeciesID: c.SpeciesID, Generation: c.Generation, X: c.X, Y: c.Y})
+}
+
+func (w *World) brainSpec() brain.Spec {
+	return brain.Spec{Type: w.Cfg.BrainType, Depth: w.Cfg.BrainDepth, Gated: w.Cfg.BrainGated}
 }
 
 func (w *World) spawnFood() {
@@ -105,13 +183,7 @@ func (w *World) Update() {
 	defer w.Mu.Unlock()
 
 	// 1. Rebuild grid
-	w.Grid.Clear()
-	for _, c := range w.Creatures {
-		w.Grid.InsertCreature(c)
-	}
-	for _, f := range w.Food {
-		w.Grid.InsertFood(f)
-	}
+	w.rebuildGrid()
 
 	var newChildren []*entity.Creature
 	var newCarrion []entity.Food
@@ -121,51 +193,60 @@ func (w *World) Update() {
 	maturityAge := int(w.Cfg.MaxAge * w.Cfg.MaturityAgeFraction)
 
 	// 2. Main Simulation Loop
-	for _, c := range w.Creatures {
+	if w.Cfg.BatchBrains {
+		w.think()
+	}
+	for i, c := range w.Creatures {
 		if deadCreatures[c.ID] {
 			continue
 		}
 
-		// Find targets
-		foodX, foodY, foodDist, foodID, foodEnergy := w.findNearestFood(c, eatenFood)
-		targetX, targetY, targetDist, targetID, targetDiet := w.findNearestCreature(c, deadCreatures)
-
-		// Continuous diet signal: maps DietGene [0,1] → [-1,1]
-		roleVal := targetDiet*2.0 - 1.0
-
-		// Get Terrain Physics
-		speedFactor, energyCostFactor := w.Terrain.GetMovementPenalty(c.X, c.Y)
-
-		// Calculate Crowding Stress
-		neighbors := 0
-		w.Grid.ForEachNeighbor(c.X, c.Y, w.Cfg.CrowdingDistance, func(other *entity.Creature) {
-			if other.ID != c.ID && !deadCreatures[other.ID] {
-				neighbors++
-			}
-		}, nil)
-		stressFactor := 1.0 + float64(neighbors)*w.Cfg.CrowdingMultiplier
+		var p perception
+		if w.Cfg.BatchBrains {
+			p = w.percepts[i]
+		} else {
+			p = w.perceive(c, eatenFood, deadCreatures)
+		}
+		foodID, foodDist, foodEnergy := p.foodID, p.foodDist, p.foodEnergy
+		targetID, targetDist := p.targetID, p.targetDist
 
-		pheromoneVal := w.Pheromone.Get(c.X, c.Y)
-		c.Update(foodX, foodY, targetX, targetY, roleVal, speedFactor, energyCostFactor, w.Cfg.WorldWidth, w.Cfg.WorldHeight, w.Cfg.MaxAge, stressFactor, pheromoneVal)
+		energyBefore := c.Energy
+		var output []float64
+		if w.Cfg.BatchBrains {
+			output = w.batch.Output(i)
+		} else {
+			output = c.Brain.FeedForward(w.Sensors.read(w, c, &p))
+		}
+		c.Act(output, w.Motors, p.speedFactor, p.energyCostFactor, w.Cfg.MaxAge, p.stressFactor)
+
+		// Intents; actions without a brain output are always on
+		eating := w.wants(c, output, ActionEat)
+		attacking := w.wants(c, output, ActionAttack)
+		mating := w.wants(c, output, ActionMate)
+		if w.wants(c, output, ActionFlee) {
+			c.Burst(c.Speed * p.speedFactor * w.Cfg.FleeBurst)
+		}
 
 		// Deposit pheromone trail
-		w.Pheromone.Deposit(c.X, c.Y, w.Cfg.PheromoneDeposit)
+		if w.wants(c, output, ActionSignal) {
+			w.Pheromone.Deposit(c.X, c.Y, w.Cfg.PheromoneDeposit)
+		}
 
 		// Boundaries
 		if c.X < 0 {
-			c.X = 0
+			c.X, c.VX = 0, 0
 		} else if c.X > w.Cfg.WorldWidth {
-			c.X = w.Cfg.WorldWidth
+			c.X, c.VX = w.Cfg.WorldWidth, 0
 		}
 		if c.Y < 0 {
-			c.Y = 0
+			c.Y, c.VY = 0, 0
 		} else if c.Y > w.Cfg.WorldHeight {
-			c.Y = w.Cfg.WorldHeight
+			c.Y, c.VY = w.Cfg.WorldHeight, 0
 		}
 
 		// Interactions — continuous diet spectrum
 		// Food eating: any creature can eat, efficiency depends on DietGene
-		if foodID != -1 && foodDist < w.Cfg.EatRadius*c.Size {
+		if eating && foodID != -1 && foodDist < w.Cfg.EatRadius*c.Size {
 			if !eatenFood[foodID] {
 				if foodEnergy > 0 {
 					// Carrion (dead creature remains): carnivores benefit more
@@ -178,35 +259,49 @@ func (w *World) Update() {
 			}
 		}
 
+		if w.Cfg.Plasticity {
+			c.Learn(energyBefore)
+		}
+
 		// Reproduction — checked BEFORE hunting (mating takes priority over predation)
-		if c.Energy > c.ReproductionThreshold && !matedThisTick[c.ID] && c.Age >= maturityAge {
+		if mating && c.Energy > c.ReproductionThreshold && !matedThisTick[c.ID] && c.Age >= maturityAge {
 			mate := w.findMate(c, deadCreatures, matedThisTick)
+			if w.Cfg.Lamarckian {
+				c.ConsolidateLearning()
+				if mate != nil {
+					mate.ConsolidateLearning()
+				}
+			}
 			var child *entity.Creature
+			mateID := 0
 			if mate != nil {
-				child = c.ReproduceSexual(mate, w.Cfg.MutationRate, w.Cfg.MutationStrength, w.Cfg.InbreedingThreshold, w.Cfg.InbreedingPenalty, w.Cfg.BrainCostPerNeuron)
+				mateID = mate.ID
+				child = c.ReproduceSexual(mate, w.Meiosis, w.Mutation, w.Cfg.InbreedingThreshold, w.Cfg.InbreedingPenalty, w.Cfg.BrainCostPerNeuron, w.visionCost())
 				matedThisTick[mate.ID] = true
 			} else if c.Energy > c.ReproductionThreshold*w.Cfg.AsexualThresholdMult {
-				child = c.ReproduceAsexual(w.Cfg.MutationRate, w.Cfg.MutationStrength, w.Cfg.BrainCostPerNeuron)
+				child = c.ReproduceAsexual(w.Mutation, w.Cfg.BrainCostPerNeuron, w.visionCost())
 			}
 			if child != nil {
 				child.ID = rand.IntN(10000000)
 				child.SpeciesID = w.SpeciesManager.Classify(child.Genome)
 				newChildren = append(newChildren, child)
+				w.recordEvent(Event{Kind: EventBirth, CreatureID: child.ID, SpeciesID: child.SpeciesID, Generation: child.Generation, ParentID: c.ID, MateID: mateID, X: child.X, Y: child.Y})
 				matedThisTick[c.ID] = true
 			}
 		}
 
 		// Hunting: only for true carnivores, and not against genetically similar creatures
 		diet := c.Genome.ExpressedDiet()
-		if targetID != -1 && targetDist < w.Cfg.EatRadius*c.Size && diet > 0.5 && !matedThisTick[c.ID] {
+		if attacking && targetID != -1 && targetDist < w.Cfg.EatRadius*c.Size && diet > 0.5 && !matedThisTick[c.ID] {
 			if !deadCreatures[targetID] {
-				target := w.getCreatureByID(targetID)
+				target := w.CreatureByID(targetID)
 				if target != nil && target.Size < c.Size*1.2 {
 					// Don't hunt your own kind (genetic similarity check)
 					if c.Genome.Distance(target.Genome) > w.Cfg.MatingDistanceThreshold {
 						c.Energy += target.Energy * diet * 0.8
 						deadCreatures[targetID] = true
 						w.SpeciesManager.RemoveCreature(target.SpeciesID)
+						w.recordEvent(Event{Kind: EventPredation, CreatureID: target.ID, SpeciesID: target.SpeciesID, Generation: target.Generation, ParentID: c.ID, X: target.X, Y: target.Y})
 						newCarrion = append(newCarrion, entity.Food{
 							ID:         rand.IntN(10000000),
 							X:          target.X,
@@ -222,6 +317,7 @@ func (w *World) Update() {
 		if c.Energy <= 0 {
 			deadCreatures[c.ID] = true
 			w.SpeciesManager.RemoveCreature(c.SpeciesID)
+			w.recordEvent(Event{Kind: EventDeath, CreatureID: c.ID, SpeciesID: c.SpeciesID, Generation: c.Generation, X: c.X, Y: c.Y})
 			// Spawn carrion from natural death
 			newCarrion = append(newCarrion, entity.Food{
 				ID:         rand.IntN(10000000),
@@ -273,9 +369,130 @@ func (w *World) Update() {
 	if len(w.Creatures) < 10 {
 		w.spawnRandomCreatures(5)
 	}
+
+	w.Tick++
+}
+
+// perception is what a creature notices around it before it acts.
+type perception struct {
+	foodX, foodY, foodDist, foodEnergy float64
+	foodID                             int
+
+	targetX, targetY, targetDist float64
+	targetID                     int
+	role                         float64 // Continuous diet signal of the target
+
+	speedFactor, energyCostFactor float64
+	neighbors                     int
+	stressFactor                  float64
+	pheromone                     float64
+
+	eaten, dead map[int]bool // Gone this tick, so rays see through them
+}
+
+func (w *World) perceive(c *entity.Creature, eatenFood, deadCreatures map[int]bool) perception {
+	p := perception{eaten: eatenFood, dead: deadCreatures}
+
+	// Find targets
+	p.foodX, p.foodY, p.foodDist, p.foodID, p.foodEnergy = w.findNearestFood(c, eatenFood)
+	var targetDiet float64
+	p.targetX, p.targetY, p.targetDist, p.targetID, targetDiet = w.findNearestCreature(c, deadCreatures)
+
+	// Continuous diet signal: maps DietGene [0,1] → [-1,1]
+	p.role = targetDiet*2.0 - 1.0
+
+	// Nothing in view reads as a zero vector and neutral diet rather than a
+	// target at the origin; the *_seen sensors tell it apart from "right here"
+	if p.foodID == -1 {
+		p.foodX, p.foodY = c.X, c.Y
+	}
+	if p.targetID == -1 {
+		p.targetX, p.targetY, p.role = c.X, c.Y, 0
+	}
+
+	// Get Terrain Physics
+	p.speedFactor, p.energyCostFactor = w.Terrain.GetMovementPenalty(c.X, c.Y)
+
+	// Calculate Crowding Stress
+	w.Grid.ForEachNeighbor(c.X, c.Y, w.Cfg.CrowdingDistance, func(other *entity.Creature) {
+		if other.ID != c.ID && !deadCreatures[other.ID] {
+			p.neighbors++
+		}
+	}, nil)
+	p.stressFactor = 1.0 + float64(p.neighbors)*w.Cfg.CrowdingMultiplier
+
+	p.pheromone = w.Pheromone.Get(c.X, c.Y)
+	return p
+}
+
+// think senses for every creature and evaluates all brains as one batch,
+// leaving the percepts and outputs indexed like w.Creatures. Unlike the
+// one-by-one path, which senses each creature in turn and so skips food
+// eaten and prey killed earlier in the tick, everything is sensed from the
+// world as it was at the start of the tick.
+func (w *World) think() {
+	if w.batch == nil {
+		w.batch = brain.NewBatch()
+	}
+	w.batch.Reset()
+	w.percepts = w.percepts[:0]
+	for _, c := range w.Creatures {
+		w.percepts = append(w.percepts, w.perceive(c, nil, nil))
+		w.batch.Add(c.Brain, w.Sensors.read(w, c, &w.percepts[len(w.percepts)-1]))
+	}
+	w.batch.Run()
+}
+
+// rebuildGrid indexes the current creatures and food.
+func (w *World) rebuildGrid() {
+	w.Grid.Clear()
+	for _, c := range w.Creatures {
+		w.Grid.InsertCreature(c)
+	}
+	for _, f := range w.Food {
+		w.Grid.InsertFood(f)
+	}
+}
+
+func mustSensorSet(cfg *config.Config) *SensorSet {
+	set, err := NewSensorSet(cfg.Sensors, cfg.VisionRayCount)
+	if err != nil {
+		panic(err) // Callers validate SENSORS first
+	}
+	return set
+}
+
+func motors(cfg *config.Config) entity.Motors {
+	return entity.Motors{Model: cfg.Motors, Strafe: cfg.Strafe, Drag: cfg.Drag, TurnRate: cfg.TurnRate}
+}
+
+func meiosis(cfg *config.Config) entity.Meiosis {
+	return entity.Meiosis{Linkage: cfg.Linkage, RecombinationRate: cfg.RecombinationRate}
+}
+
+func mutation(cfg *config.Config) entity.Mutation {
+	return entity.Mutation{
+		Rate:       cfg.MutationRate,
+		Strength:   cfg.MutationStrength,
+		MarkerRate: cfg.MarkerRate,
+		Adaptive:   cfg.AdaptiveMutation,
+		Range:      cfg.MutationRange,
+	}
+}
+
+// OutputNames labels the brain outputs, in order.
+func (w *World) OutputNames() []string {
+	return append(w.Motors.Names(), w.Actions.Names()...)
+}
+
+// visionCost is the BMR of vision rays per radian of field of view.
+func (w *World) visionCost() float64 {
+	return w.Sensors.VisionCost(w.Cfg.VisionRayCost)
 }
 
-func (w *World) getCreatureByID(id int) *entity.Creature {
+// CreatureByID returns the living creature with the given ID, or nil. The
+// caller must hold w.Mu.
+func (w *World) CreatureByID(id int) *entity.Creature {
 	for _, c := range w.Creatures {
 		if c.ID == id {
 			return c
@@ -0,0 +1,104 @@
+package world
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"evo-sim/internal/config"
+	"evo-sim/internal/entity"
+)
+
+// testConfig loads the defaults with the given environment overrides.
+func testConfig(t *testing.T, env map[string]string) *config.Config {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+	for k, v := range env {
+		t.Setenv(k, v)
+	}
+	return config.Load()
+}
+
+// twin returns a world in the same state as w, with its own copies of the
+// creatures and brains, run with cfg.
+func twin(t *testing.T, w *World, cfg *config.Config) *World {
+	data, err := json.Marshal(w.Creatures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var creatures []*entity.Creature
+	if err := json.Unmarshal(data, &creatures); err != nil {
+		t.Fatal(err)
+	}
+	return NewWorldFromState(cfg, w.Terrain, creatures, append([]entity.Food(nil), w.Food...), w.Tick)
+}
+
+func TestThink_BatchMatchesSerial(t *testing.T) {
+	cfg := testConfig(t, map[string]string{
+		"INITIAL_POP": "60",
+		"SENSORS":     "food,food_seen,creature,creature_seen,energy,target_diet,walls,pheromone,rays",
+	})
+	serial := NewWorld(cfg)
+	batchCfg := *cfg
+	batchCfg.BatchBrains = true
+	batched := twin(t, serial, &batchCfg)
+	serial = twin(t, serial, cfg) // Same fresh brain state on both sides
+
+	// At the start of a tick nothing is eaten or dead yet, so sensing one by
+	// one sees what the batch sees
+	for tick := 0; tick < 5; tick++ {
+		serial.rebuildGrid()
+		batched.rebuildGrid()
+		batched.think()
+		for i, c := range serial.Creatures {
+			p := serial.perceive(c, nil, nil)
+			if !reflect.DeepEqual(p, batched.percepts[i]) {
+				t.Fatalf("tick %d creature %d perceives %+v serially, %+v batched", tick, c.ID, p, batched.percepts[i])
+			}
+			want, got := c.Brain.FeedForward(serial.Sensors.read(serial, c, &p)), batched.batch.Output(i)
+			for k := range want {
+				if math.Abs(got[k]-want[k]) > 1e-4 {
+					t.Fatalf("tick %d creature %d output %d: batched %f, serial %f", tick, c.ID, k, got[k], want[k])
+				}
+			}
+		}
+	}
+}
+
+func TestPerceive_SkipsEatenFoodAndDeadCreatures(t *testing.T) {
+	cfg := testConfig(t, map[string]string{"INITIAL_POP": "60", "FOOD_COUNT": "200"})
+	w := NewWorld(cfg)
+	w.rebuildGrid()
+
+	for _, c := range w.Creatures {
+		p := w.perceive(c, nil, nil)
+		if p.foodID == -1 && p.targetID == -1 {
+			continue
+		}
+		eaten, dead := map[int]bool{p.foodID: true}, map[int]bool{p.targetID: true}
+		gone := w.perceive(c, eaten, dead)
+		if p.foodID != -1 && gone.foodID == p.foodID {
+			t.Errorf("creature %d still sees eaten food %d", c.ID, p.foodID)
+		}
+		if p.targetID != -1 && gone.targetID == p.targetID {
+			t.Errorf("creature %d still sees dead creature %d", c.ID, p.targetID)
+		}
+	}
+}
+
+func TestThink_BatchedDoesNotAllocate(t *testing.T) {
+	cfg := testConfig(t, map[string]string{"INITIAL_POP": "60", "BATCH_BRAINS": "true"})
+	w := NewWorld(cfg)
+	w.rebuildGrid()
+	w.think() // Size the buffers
+
+	if n := testing.AllocsPerRun(10, w.think); n != 0 {
+		t.Errorf("think allocates %.0f times per tick", n)
+	}
+}
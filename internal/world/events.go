@@ -0,0 +1,57 @@
+package world
+
+type EventKind uint8
+
+const (
+	EventBirth EventKind = iota + 1
+	EventDeath
+	EventPredation
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventBirth:
+		return "birth"
+	case EventDeath:
+		return "death"
+	case EventPredation:
+		return "predation"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is a notable change in the population, recorded during Update.
+type Event struct {
+	Tick       int64     `json:"tick"`
+	Kind       EventKind `json:"kind"`
+	CreatureID int       `json:"creatureId"`
+	SpeciesID  int       `json:"speciesId"`
+	Generation int       `json:"generation"`
+	ParentID   int       `json:"parentId,omitempty"` // Birth: reproducing parent. Predation: the predator.
+	MateID     int       `json:"mateId,omitempty"`   // Birth: second parent of sexual offspring
+	X          float64   `json:"x"`
+	Y          float64   `json:"y"`
+}
+
+// maxPendingEvents bounds the event buffer when nobody drains it.
+const maxPendingEvents = 100000
+
+func (w *World) recordEvent(e Event) {
+	e.Tick = w.Tick
+	if len(w.events) >= maxPendingEvents {
+		// Drop the oldest half rather than growing without bound
+		w.events = append(w.events[:0], w.events[maxPendingEvents/2:]...)
+	}
+	w.events = append(w.events, e)
+}
+
+// DrainEvents returns and clears the events recorded since the last call.
+func (w *World) DrainEvents() []Event {
+	w.Mu.Lock()
+	defer w.Mu.Unlock()
+
+	events := w.events
+	w.events = nil
+	return events
+}
@@ -0,0 +1,91 @@
+package world
+
+import (
+	"fmt"
+	"log"
+	"math/rand/v2"
+
+	"evo-sim/internal/brain"
+	"evo-sim/internal/entity"
+)
+
+// CheckSeeds reports the first genome code that can't live in a world with
+// these sensors and outputs, for validating SEED_GENOMES.
+func CheckSeeds(codes []string, sensors *SensorSet, outputs int) error {
+	for i, code := range codes {
+		g, b, err := entity.DecodeCode(code)
+		if err == nil {
+			err = checkBrain(g, b, sensors, outputs)
+		}
+		if err != nil {
+			return fmt.Errorf("code %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// checkBrain tells why brain b of genome g doesn't fit the sensors and
+// outputs. Genomes without a brain always fit; they get a new one.
+func checkBrain(g entity.Genome, b brain.Brain, sensors *SensorSet, outputs int) error {
+	if b == nil {
+		return nil
+	}
+	if n := b.Layers(); n < 1 || n > entity.MaxHiddenLayers {
+		return fmt.Errorf("brain has %d hidden layers, not 1 to %d", n, entity.MaxHiddenLayers)
+	}
+	if !sensors.Fits(g) {
+		return fmt.Errorf("brain senses %s, not %s", GenomeSensors(g), sensors.Key())
+	}
+	if in, _, out := b.Shape(); in != sensors.Size() || out != outputs {
+		return fmt.Errorf("brain has %d inputs/%d outputs, not %d/%d", in, out, sensors.Size(), outputs)
+	}
+	return nil
+}
+
+// Spawn adds a first-generation creature with genome g on land. A nil brain
+// is replaced by a random one for the world's sensors; a given one must fit
+// them. The caller must hold w.Mu.
+func (w *World) Spawn(g entity.Genome, b brain.Brain) (*entity.Creature, error) {
+	if err := checkBrain(g, b, w.Sensors, len(w.OutputNames())); err != nil {
+		return nil, err
+	}
+	if b == nil {
+		g.Sensors = w.Sensors.Key()
+	} else {
+		brain.Adopt(b)
+	}
+	// Seeds must land somewhere, so after enough tries water will do
+	x, y, _ := w.landSpot(20)
+	c := entity.NewCreatureWithGenome(rand.IntN(10000000), x, y, g, b, w.brainSpec(), w.Sensors.Size(), len(w.OutputNames()), w.Cfg.BrainCostPerNeuron, w.visionCost())
+	w.addFounder(c)
+	return c, nil
+}
+
+// spawnSeeds spawns the initial population, cycling through SEED_GENOMES or
+// at random if there are none. Codes that can't live in this world are
+// skipped with a warning; if none can, the population is random.
+func (w *World) spawnSeeds(count int) {
+	var codes []string
+	for i, code := range w.Cfg.SeedGenomes {
+		if err := CheckSeeds([]string{code}, w.Sensors, len(w.OutputNames())); err != nil {
+			log.Printf("Skipping SEED_GENOMES code %d: %v", i+1, err)
+			continue
+		}
+		codes = append(codes, code)
+	}
+	if len(codes) == 0 {
+		if len(w.Cfg.SeedGenomes) > 0 {
+			log.Printf("No usable SEED_GENOMES, spawning %d random creatures", count)
+		}
+		w.spawnRandomCreatures(count)
+		return
+	}
+	for i := 0; i < count; i++ {
+		// Decoded for every creature so none share a brain
+		g, b, _ := entity.DecodeCode(codes[i%len(codes)])
+		if _, err := w.Spawn(g, b); err != nil {
+			log.Printf("Spawning a random creature instead of a seed: %v", err)
+			w.spawnRandomCreatures(1)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package world
+
+import (
+	"testing"
+
+	"evo-sim/internal/brain"
+	"evo-sim/internal/entity"
+)
+
+func TestNewWorld_SkipsUnusableSeeds(t *testing.T) {
+	g := entity.NewRandomGenome()
+	g.Alleles[entity.LocusSize] = [2]float64{2.5, 2.5}
+	code, err := entity.EncodeCode(g, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, seeds := range map[string]string{
+		"bad":   "evo1.garbage",
+		"mixed": "evo1.garbage," + code,
+	} {
+		cfg := testConfig(t, map[string]string{"INITIAL_POP": "10", "SEED_GENOMES": seeds})
+		w := NewWorld(cfg)
+		// Random founders that find no land are dropped, seeds never are
+		if len(w.Creatures) == 0 || name == "mixed" && len(w.Creatures) != 10 {
+			t.Fatalf("%s seeds: %d creatures, want 10", name, len(w.Creatures))
+		}
+		if name == "mixed" {
+			for _, c := range w.Creatures {
+				if c.Genome.Alleles[entity.LocusSize] != g.Alleles[entity.LocusSize] {
+					t.Fatalf("creature not grown from the usable seed: size %v", c.Genome.Alleles[entity.LocusSize])
+				}
+			}
+		}
+	}
+}
+
+func TestCheckSeeds_RejectsBrainsWithoutHiddenLayers(t *testing.T) {
+	cfg := testConfig(t, map[string]string{"INITIAL_POP": "0", "BRAIN_TYPE": "deep"})
+	w := NewWorld(cfg)
+	outputs := len(w.OutputNames())
+	flat, _ := entity.EncodeCode(entity.NewRandomGenome(), brain.NewDeepNetwork(w.Sensors.Size(), nil, outputs, false))
+	deep, _ := entity.EncodeCode(entity.NewRandomGenome(), brain.NewDeepNetwork(w.Sensors.Size(), []int{4, 4, 4, 4, 4}, outputs, false))
+
+	for name, code := range map[string]string{"no hidden layers": flat, "too many layers": deep} {
+		if err := CheckSeeds([]string{code}, w.Sensors, outputs); err == nil {
+			t.Errorf("%s: code accepted", name)
+		}
+	}
+
+	cfg = testConfig(t, map[string]string{"INITIAL_POP": "3", "BRAIN_TYPE": "deep", "SEED_GENOMES": flat})
+	if w := NewWorld(cfg); len(w.Creatures) == 0 {
+		t.Errorf("no random creatures instead of the unusable seed")
+	}
+}
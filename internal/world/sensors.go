@@ -0,0 +1,237 @@
+package world
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"evo-sim/internal/entity"
+)
+
+// DefaultSensors is the input layout brains had before sensors were
+// configurable. Genomes without a recorded layout use it.
+var DefaultSensors = []string{"food", "creature", "energy", "target_diet", "walls", "pheromone"}
+
+// sensor writes len(inputs) brain inputs for a creature. The rays sensor
+// repeats its inputs for each of VISION_RAY_COUNT rays.
+type sensor struct {
+	name   string
+	inputs []string
+	read   func(w *World, c *entity.Creature, p *perception, out []float64)
+}
+
+const raysSensor = "rays"
+
+// clockPeriod is the period of the clock sensor in ticks (10s at 60 TPS).
+const clockPeriod = 600
+
+// sensorRegistry lists every sensor SENSORS can select. Inputs are
+// normalized to roughly [-1, 1] where possible.
+var sensorRegistry = []sensor{
+	{"food", []string{"food_dx", "food_dy"}, func(w *World, c *entity.Creature, p *perception, out []float64) {
+		out[0], out[1] = w.relative(c, p.foodX-c.X, p.foodY-c.Y)
+	}},
+	{"creature", []string{"creature_dx", "creature_dy"}, func(w *World, c *entity.Creature, p *perception, out []float64) {
+		out[0], out[1] = w.relative(c, p.targetX-c.X, p.targetY-c.Y)
+	}},
+	{"food_seen", []string{"food_seen", "food_near"}, func(w *World, c *entity.Creature, p *perception, out []float64) {
+		out[0], out[1] = presence(p.foodID, p.foodDist, c.ViewRadius)
+	}},
+	{"creature_seen", []string{"creature_seen", "creature_near"}, func(w *World, c *entity.Creature, p *perception, out []float64) {
+		out[0], out[1] = presence(p.targetID, p.targetDist, c.ViewRadius)
+	}},
+	{"energy", []string{"energy"}, func(w *World, c *entity.Creature, p *perception, out []float64) {
+		out[0] = c.Energy / c.MaxEnergy
+	}},
+	{"target_diet", []string{"target_diet"}, func(w *World, c *entity.Creature, p *perception, out []float64) {
+		out[0] = p.role
+	}},
+	{"walls", []string{"wall_left", "wall_right", "wall_top", "wall_bottom"}, func(w *World, c *entity.Creature, p *perception, out []float64) {
+		out[0] = c.X / w.Cfg.WorldWidth
+		out[1] = (w.Cfg.WorldWidth - c.X) / w.Cfg.WorldWidth
+		out[2] = c.Y / w.Cfg.WorldHeight
+		out[3] = (w.Cfg.WorldHeight - c.Y) / w.Cfg.WorldHeight
+	}},
+	{"pheromone", []string{"pheromone"}, func(w *World, c *entity.Creature, p *perception, out []float64) {
+		out[0] = p.pheromone / 10.0 // Capped at 10.0
+	}},
+	{"age", []string{"age"}, func(w *World, c *entity.Creature, p *perception, out []float64) {
+		out[0] = float64(c.Age) / w.Cfg.MaxAge
+	}},
+	{"crowding", []string{"crowding"}, func(w *World, c *entity.Creature, p *perception, out []float64) {
+		out[0] = math.Min(float64(p.neighbors)/10.0, 1.0)
+	}},
+	{"clock", []string{"clock_sin", "clock_cos"}, func(w *World, c *entity.Creature, p *perception, out []float64) {
+		phase := 2 * math.Pi * float64(w.Tick%clockPeriod) / clockPeriod
+		out[0], out[1] = math.Sin(phase), math.Cos(phase)
+	}},
+	{"motion", []string{"forward_speed", "side_speed"}, func(w *World, c *entity.Creature, p *perception, out []float64) {
+		cos, sin := math.Cos(c.Heading), math.Sin(c.Heading)
+		out[0] = (c.VX*cos + c.VY*sin) / c.Speed
+		out[1] = (c.VY*cos - c.VX*sin) / c.Speed
+	}},
+	{"terrain", []string{"on_water", "on_sand", "on_grass"}, func(w *World, c *entity.Creature, p *perception, out []float64) {
+		here := w.Terrain.GetType(c.X, c.Y)
+		for i, tt := range terrainTypes {
+			out[i] = 0
+			if tt == here {
+				out[i] = 1
+			}
+		}
+	}},
+	{"biomes", []string{"water_near", "water_dx", "water_dy", "sand_near", "sand_dx", "sand_dy", "grass_near", "grass_dx", "grass_dy"}, func(w *World, c *entity.Creature, p *perception, out []float64) {
+		for i, tt := range terrainTypes {
+			x, y, dist, ok := w.Terrain.Nearest(c.X, c.Y, tt)
+			if !ok || dist > c.ViewRadius {
+				out[3*i], out[3*i+1], out[3*i+2] = 0, 0, 0
+				continue
+			}
+			out[3*i] = 1 - dist/c.ViewRadius
+			out[3*i+1], out[3*i+2] = w.relative(c, x-c.X, y-c.Y)
+		}
+	}},
+	{"terrain_gradient", []string{"uphill_x", "uphill_y"}, func(w *World, c *entity.Creature, p *perception, out []float64) {
+		gx, gy := w.Terrain.Gradient(c.X, c.Y)
+		out[0], out[1] = w.orient(c, gx, gy)
+	}},
+	{raysSensor, rayInputs, (*World).castRays},
+}
+
+// relative scales an offset by view radius and orients it.
+func (w *World) relative(c *entity.Creature, dx, dy float64) (float64, float64) {
+	return w.orient(c, dx/c.ViewRadius, dy/c.ViewRadius)
+}
+
+// orient turns a vector into the creature's frame with thrust motors: ahead
+// along x, to its right along y. Direct motors keep world axes.
+func (w *World) orient(c *entity.Creature, dx, dy float64) (float64, float64) {
+	if w.Motors.Model != entity.MotorsThrust {
+		return dx, dy
+	}
+	cos, sin := math.Cos(c.Heading), math.Sin(c.Heading)
+	return dx*cos + dy*sin, dy*cos - dx*sin
+}
+
+// presence returns 1 and a nearness falling from 1 to 0 at the edge of view
+// if something was seen, and zeros otherwise.
+func presence(id int, dist, viewRadius float64) (seen, near float64) {
+	if id == -1 {
+		return 0, 0
+	}
+	return 1, 1 - math.Min(dist/viewRadius, 1)
+}
+
+// SensorSet is a selection of sensors in input order.
+type SensorSet struct {
+	sensors []*sensor
+	widths  []int    // Inputs per sensor
+	names   []string // Input names
+	key     string
+	rays    int // Vision rays, 0 without the rays sensor
+}
+
+// NewSensorSet selects sensors by name. The rays sensor casts rays rays.
+func NewSensorSet(names []string, rays int) (*SensorSet, error) {
+	set := &SensorSet{}
+	keys := make([]string, len(names))
+	seen := map[string]bool{}
+	for _, name := range names {
+		s := findSensor(name)
+		if s == nil {
+			return nil, fmt.Errorf("unknown sensor %q (known: %s)", name, strings.Join(SensorNames(), ", "))
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("sensor %q selected twice", name)
+		}
+		seen[name] = true
+		keys[len(set.sensors)] = name
+		set.sensors = append(set.sensors, s)
+		if name != raysSensor {
+			set.widths = append(set.widths, len(s.inputs))
+			set.names = append(set.names, s.inputs...)
+			continue
+		}
+
+		if rays < 1 {
+			return nil, fmt.Errorf("sensor %q needs VISION_RAY_COUNT of at least 1", name)
+		}
+		set.rays = rays
+		keys[len(set.sensors)-1] = fmt.Sprintf("%s:%d", name, rays)
+		set.widths = append(set.widths, rays*len(s.inputs))
+		for r := 0; r < rays; r++ {
+			for _, input := range s.inputs {
+				set.names = append(set.names, fmt.Sprintf("ray%d_%s", r, input))
+			}
+		}
+	}
+	if len(set.sensors) == 0 {
+		return nil, fmt.Errorf("no sensors selected")
+	}
+	set.key = strings.Join(keys, ",")
+	return set, nil
+}
+
+func findSensor(name string) *sensor {
+	for i := range sensorRegistry {
+		if sensorRegistry[i].name == name {
+			return &sensorRegistry[i]
+		}
+	}
+	return nil
+}
+
+// SensorNames lists the sensors that can be selected.
+func SensorNames() []string {
+	names := make([]string, len(sensorRegistry))
+	for i, s := range sensorRegistry {
+		names[i] = s.name
+	}
+	return names
+}
+
+// Size is the number of brain inputs.
+func (s *SensorSet) Size() int {
+	return len(s.names)
+}
+
+// InputNames names each brain input.
+func (s *SensorSet) InputNames() []string {
+	return s.names
+}
+
+// VisionCost is the BMR vision rays cost per radian of field of view, given
+// the cost of one ray.
+func (s *SensorSet) VisionCost(costPerRay float64) float64 {
+	return float64(s.rays) * costPerRay
+}
+
+// Key identifies the layout; genomes record it in Genome.Sensors. Per-ray
+// sensors are recorded with their ray count, as in "rays:5".
+func (s *SensorSet) Key() string {
+	return s.key
+}
+
+// Fits reports whether a genome's brain was built for this layout.
+func (s *SensorSet) Fits(g entity.Genome) bool {
+	return GenomeSensors(g) == s.key
+}
+
+// GenomeSensors returns the layout key a genome's brain was built for.
+func GenomeSensors(g entity.Genome) string {
+	if g.Sensors == "" {
+		return strings.Join(DefaultSensors, ",")
+	}
+	return g.Sensors
+}
+
+// read fills the creature's input buffer.
+func (s *SensorSet) read(w *World, c *entity.Creature, p *perception) []float64 {
+	in := c.SensorBuffer(len(s.names))
+	offset := 0
+	for i, sensor := range s.sensors {
+		n := s.widths[i]
+		sensor.read(w, c, p, in[offset:offset+n])
+		offset += n
+	}
+	return in
+}
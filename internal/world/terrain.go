@@ -17,9 +17,11 @@ const (
 // TerrainGrid holds the static map data.
 // Resolution: 1 cell represents Scale x Scale world units.
 type TerrainGrid struct {
-	Width, Height int       // Dimensions in grid cells
-	Scale         float64   // World units per cell (e.g., 20.0)
+	Width, Height int     // Dimensions in grid cells
+	Scale         float64 // World units per cell (e.g., 20.0)
 	Cells         []TerrainType
+
+	nearest map[TerrainType][]int32 // Closest cell of each type, see Nearest
 }
 
 func NewTerrainGrid(worldW, worldH, scale float64) *TerrainGrid {
@@ -40,11 +42,11 @@ func NewTerrainGrid(worldW, worldH, scale float64) *TerrainGrid {
 func (t *TerrainGrid) Generate() {
 	// Simple Perlin-like noise using overlapping sine waves
 	seed := rand.Float64() * 100
-	
+
 	// Increased frequencies to fit more features into small grid (40x30)
-	freq1 := 0.25 
+	freq1 := 0.25
 	freq2 := 0.8
-	
+
 	counts := map[TerrainType]int{Water: 0, Sand: 0, Grass: 0}
 	total := t.Width * t.Height
 
@@ -52,11 +54,11 @@ func (t *TerrainGrid) Generate() {
 		for x := 0; x < t.Width; x++ {
 			nx := float64(x)
 			ny := float64(y)
-			
+
 			// Combine waves
-			val := math.Sin(nx*freq1 + seed) * math.Cos(ny*freq1 + seed)
-			val += (math.Sin(nx*freq2 - seed) + math.Cos(ny*freq2 + seed*0.5)) * 0.2
-			
+			val := math.Sin(nx*freq1+seed) * math.Cos(ny*freq1+seed)
+			val += (math.Sin(nx*freq2-seed) + math.Cos(ny*freq2+seed*0.5)) * 0.2
+
 			idx := y*t.Width + x
 			if val < -0.2 {
 				t.Cells[idx] = Water
@@ -70,7 +72,7 @@ func (t *TerrainGrid) Generate() {
 			}
 		}
 	}
-	
+
 	log.Printf("Terrain Generated: Water %.1f%%, Sand %.1f%%, Grass %.1f%%",
 		float64(counts[Water])/float64(total)*100,
 		float64(counts[Sand])/float64(total)*100,
@@ -83,12 +85,20 @@ func (t *TerrainGrid) GetType(worldX, worldY float64) TerrainType {
 	gy := int(worldY / t.Scale)
 
 	// Clamp
-	if gx < 0 { gx = 0 }
-	if gx >= t.Width { gx = t.Width - 1 }
-	if gy < 0 { gy = 0 }
-	if gy >= t.Height { gy = t.Height - 1 }
+	if gx < 0 {
+		gx = 0
+	}
+	if gx >= t.Width {
+		gx = t.Width - 1
+	}
+	if gy < 0 {
+		gy = 0
+	}
+	if gy >= t.Height {
+		gy = t.Height - 1
+	}
 
-	return t.Cells[gy*t.Width + gx]
+	return t.Cells[gy*t.Width+gx]
 }
 
 func (t *TerrainGrid) GetMovementPenalty(worldX, worldY float64) (speedFactor, energyCostFactor float64) {
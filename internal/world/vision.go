@@ -0,0 +1,118 @@
+package world
+
+import (
+	"math"
+
+	"evo-sim/internal/entity"
+)
+
+// Ray channels, in input order. Hits set near and one of the kind channels;
+// creature hits also set diet and size.
+const (
+	rayNear = iota
+	rayFood
+	rayCarrion
+	rayCreature
+	rayWater
+	rayWall
+	rayDiet // Target diet mapped to [-1, 1]
+	raySize // Target size relative to the viewer, in [-1, 1]
+	rayChannels
+)
+
+var rayInputs = []string{"near", "food", "carrion", "creature", "water", "wall", "diet", "size"}
+
+// Hit radii of things a ray can strike, matching how the client draws them.
+const (
+	foodHitRadius     = 3.0
+	creatureHitRadius = 5.0 // Times Size
+)
+
+// castRays fans len(out)/rayChannels rays across the creature's field of view,
+// centred on its heading, and writes what each one hits first within view
+// radius. Water only counts when the creature isn't already in it.
+func (w *World) castRays(c *entity.Creature, p *perception, out []float64) {
+	n := len(out) / rayChannels
+	fov := c.Genome.ExpressedFOV()
+
+	// Gather what's in view once; every ray tests the same candidates
+	w.rayCreatures = w.rayCreatures[:0]
+	w.rayFood = w.rayFood[:0]
+	w.Grid.ForEachNeighbor(c.X, c.Y, c.ViewRadius, func(other *entity.Creature) {
+		if other.ID != c.ID && !p.dead[other.ID] {
+			w.rayCreatures = append(w.rayCreatures, other)
+		}
+	}, func(f entity.Food) {
+		if !p.eaten[f.ID] {
+			w.rayFood = append(w.rayFood, f)
+		}
+	})
+	inWater := w.Terrain.GetType(c.X, c.Y) == Water
+
+	for r := 0; r < n; r++ {
+		angle := c.Heading
+		if n > 1 {
+			angle += fov * (float64(r)/float64(n-1) - 0.5)
+		}
+		dx, dy := math.Cos(angle), math.Sin(angle)
+		ray := out[r*rayChannels : (r+1)*rayChannels]
+		clear(ray)
+
+		best, kind := w.rayTerrain(c, dx, dy, inWater)
+		var hit *entity.Creature
+		for _, f := range w.rayFood {
+			if d := rayCircle(c.X, c.Y, dx, dy, f.X, f.Y, foodHitRadius); d < best {
+				best, kind = d, rayFood
+				if f.Energy > 0 {
+					kind = rayCarrion
+				}
+			}
+		}
+		for _, other := range w.rayCreatures {
+			if d := rayCircle(c.X, c.Y, dx, dy, other.X, other.Y, creatureHitRadius*other.Size); d < best {
+				best, kind, hit = d, rayCreature, other
+			}
+		}
+
+		if kind < 0 {
+			continue
+		}
+		ray[rayNear] = 1 - best/c.ViewRadius
+		ray[kind] = 1
+		if kind == rayCreature {
+			ray[rayDiet] = hit.Genome.ExpressedDiet()*2 - 1
+			ray[raySize] = math.Max(-1, math.Min(1, hit.Size/c.Size-1))
+		}
+	}
+}
+
+// rayTerrain steps along a ray at half the terrain resolution and returns the
+// distance to the first wall or water cell within view, or view radius and -1.
+func (w *World) rayTerrain(c *entity.Creature, dx, dy float64, inWater bool) (float64, int) {
+	step := w.Terrain.Scale / 2
+	for d := step; d < c.ViewRadius; d += step {
+		x, y := c.X+dx*d, c.Y+dy*d
+		if x < 0 || y < 0 || x >= w.Cfg.WorldWidth || y >= w.Cfg.WorldHeight {
+			return d, rayWall
+		}
+		if !inWater && w.Terrain.GetType(x, y) == Water {
+			return d, rayWater
+		}
+	}
+	return c.ViewRadius, -1
+}
+
+// rayCircle returns how far along the unit ray from (ox, oy) it enters the
+// circle, or +Inf if it misses or the circle is behind.
+func rayCircle(ox, oy, dx, dy, cx, cy, radius float64) float64 {
+	fx, fy := cx-ox, cy-oy
+	along := fx*dx + fy*dy
+	if along < 0 {
+		return math.Inf(1)
+	}
+	off := fx*fx + fy*fy - along*along
+	if off > radius*radius {
+		return math.Inf(1)
+	}
+	return math.Max(0, along-math.Sqrt(radius*radius-off))
+}
@@ -9,6 +9,12 @@ const uiFps = document.getElementById('stat-fps');
 const uiUptime = document.getElementById('stat-uptime');
 const uiStatus = document.getElementById('connection-status');
 
+const pbRewind = document.getElementById('pb-rewind');
+const pbToggle = document.getElementById('pb-toggle');
+const pbSpeed = document.getElementById('pb-speed');
+const pbLive = document.getElementById('pb-live');
+const pbTimeline = document.getElementById('pb-timeline');
+
 let startTime = Date.now();
 
 
@@ -19,6 +25,9 @@ const wsUrl = `${protocol}://${host}/ws`;
 let socket;
 let lastFrameTime = performance.now();
 let frameCount = 0;
+let playback = { live: true, paused: false };
+let frameVersion = 2; // Recordings made before rotation was sent report none
+let scrubbing = false;
 
 // Fetch static map data
 fetch('/api/map')
@@ -28,6 +37,7 @@ fetch('/api/map')
         if (data.startTime) {
             startTime = data.startTime;
         }
+        frameVersion = data.frameVersion || 1;
         renderer.setMap(data.terrain);
     })
     .catch(err => console.error("Failed to load map:", err));
@@ -54,6 +64,12 @@ function connect() {
     };
 
     socket.onmessage = (event) => {
+        // Text messages carry playback status, binary ones world frames
+        if (typeof event.data === 'string') {
+            updatePlayback(JSON.parse(event.data));
+            return;
+        }
+
         const buffer = event.data;
         const state = parseWorldState(buffer);
 
@@ -64,6 +80,37 @@ function connect() {
 }
 
 
+function sendCommand(cmd) {
+    if (socket && socket.readyState === WebSocket.OPEN) {
+        socket.send(JSON.stringify(cmd));
+    }
+}
+
+function updatePlayback(status) {
+    if (status.type !== 'playback') return;
+    playback = status;
+
+    pbToggle.innerHTML = status.paused ? '&#9654;' : '&#10074;&#10074;';
+    pbLive.classList.toggle('active', status.live);
+    pbSpeed.value = String(status.speed);
+
+    pbTimeline.min = status.oldestTick;
+    pbTimeline.max = status.newestTick;
+    if (!scrubbing) {
+        pbTimeline.value = status.live ? status.newestTick : status.tick;
+    }
+}
+
+pbRewind.addEventListener('click', () => sendCommand({ cmd: 'rewind', seconds: 30 }));
+pbToggle.addEventListener('click', () => sendCommand({ cmd: playback.paused ? 'play' : 'pause' }));
+pbSpeed.addEventListener('change', () => sendCommand({ cmd: 'speed', speed: parseFloat(pbSpeed.value) }));
+pbLive.addEventListener('click', () => sendCommand({ cmd: 'live' }));
+pbTimeline.addEventListener('input', () => { scrubbing = true; });
+pbTimeline.addEventListener('change', () => {
+    scrubbing = false;
+    sendCommand({ cmd: 'seek', tick: parseInt(pbTimeline.value, 10) });
+});
+
 function parseWorldState(buffer) {
     const view = new DataView(buffer);
     let offset = 0;
@@ -95,7 +142,13 @@ function parseWorldState(buffer) {
         const b = view.getUint8(offset);
         offset += 1;
 
-        creatures.push({ id, x, y, isCarnivore, size, color: { r, g, b } });
+        let rotation = null;
+        if (frameVersion >= 2) {
+            rotation = view.getUint8(offset) / 256 * Math.PI * 2;
+            offset += 1;
+        }
+
+        creatures.push({ id, x, y, isCarnivore, size, rotation, color: { r, g, b } });
     }
 
     // --- 2. Food ---
@@ -144,6 +144,16 @@ export class Renderer {
                     this.ctx.lineWidth = 2;
                     this.ctx.stroke();
                 }
+
+                // Heading tick
+                if (c.rotation !== null && c.rotation !== undefined) {
+                    this.ctx.beginPath();
+                    this.ctx.moveTo(c.x, c.y);
+                    this.ctx.lineTo(c.x + Math.cos(c.rotation) * radius * 1.6, c.y + Math.sin(c.rotation) * radius * 1.6);
+                    this.ctx.strokeStyle = this.ctx.fillStyle;
+                    this.ctx.lineWidth = 1.5;
+                    this.ctx.stroke();
+                }
             }
         }
 